@@ -0,0 +1,230 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/k5sha/Tikceto/internal/mailer"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+// UserExport is every piece of personal data kept about a user.
+type UserExport struct {
+	ExportedAt       string          `json:"exported_at"`
+	Profile          *store.User     `json:"profile"`
+	TwoFactorEnabled bool            `json:"two_factor_enabled"`
+	Tickets          []store.Ticket  `json:"tickets"`
+	Payments         []store.Payment `json:"payments"`
+}
+
+// exportUserDataHandler godoc
+//
+//	@Summary		Exports personal data
+//	@Description	Downloads the profile, tickets and payments of the current user as a JSON document or a ZIP archive
+//	@Tags			users
+//	@Produce		json
+//	@Produce		application/zip
+//	@Param			format	query		string	false	"Archive format (json|zip)"
+//	@Success		200		{object}	UserExport
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/export [get]
+func (app *application) exportUserDataHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "zip" {
+		app.badRequestResponse(w, r, fmt.Errorf("format must be json or zip"))
+		return
+	}
+
+	user := getUserFromCtx(r)
+	ctx := r.Context()
+
+	twoFactor, err := app.store.TwoFactor.Get(ctx, user.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	tickets, err := app.store.Tickets.GetByUserID(ctx, user.ID)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			app.internalServerError(w, r, err)
+			return
+		}
+		tickets = []store.Ticket{}
+	}
+
+	payments, err := app.store.Payments.GetByUserID(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	export := UserExport{
+		ExportedAt:       time.Now().UTC().Format(time.RFC3339),
+		Profile:          user,
+		TwoFactorEnabled: twoFactor != nil && twoFactor.Enabled,
+		Tickets:          tickets,
+		Payments:         payments,
+	}
+
+	filename := fmt.Sprintf("tikceto-export-%d", user.ID)
+
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	if format == "json" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		if err := writeJSON(w, http.StatusOK, export); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	archive, err := exportArchive(export)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
+
+// exportArchive packs the export into a ZIP archive with one JSON file per
+// kind of data.
+func exportArchive(export UserExport) ([]byte, error) {
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", struct {
+			ExportedAt       string      `json:"exported_at"`
+			Profile          *store.User `json:"profile"`
+			TwoFactorEnabled bool        `json:"two_factor_enabled"`
+		}{export.ExportedAt, export.Profile, export.TwoFactorEnabled}},
+		{"tickets.json", export.Tickets},
+		{"payments.json", export.Payments},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, file := range files {
+		f, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// requestAccountDeletionHandler godoc
+//
+//	@Summary		Requests account deletion
+//	@Description	Emails the current user a link that confirms the deletion of their account
+//	@Tags			users
+//	@Produce		json
+//	@Success		202	{string}	string	"Confirmation link sent"
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me [delete]
+func (app *application) requestAccountDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	ctx := r.Context()
+
+	plainToken := uuid.New().String()
+
+	hash := sha256.Sum256([]byte(plainToken))
+	hashToken := hex.EncodeToString(hash[:])
+
+	if err := app.store.Users.CreateAccountDeletion(ctx, user.ID, hashToken, app.config.auth.accountDeletionExp); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	vars := struct {
+		Username   string
+		ConfirmURL string
+	}{
+		Username:   user.Username,
+		ConfirmURL: fmt.Sprintf("%s/account/delete/%s", app.config.frontendURL, plainToken),
+	}
+
+	if err := app.enqueueMail(ctx, mailer.AccountDeletionTemplate, user.Username, user.Email, vars); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusAccepted, "a confirmation link has been sent to your email"); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// confirmAccountDeletionHandler godoc
+//
+//	@Summary		Deletes an account
+//	@Description	Deletes the account the confirmation token was issued for. Personal data is removed; orders,
+//	@Description	tickets and payments are kept for accounting without a link to the account.
+//	@Tags			users
+//	@Produce		json
+//	@Param			token	path		string	true	"Account deletion token"
+//	@Success		204		{string}	string	"Account deleted"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/users/delete/{token} [put]
+func (app *application) confirmAccountDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	ctx := r.Context()
+
+	user, err := app.store.Users.DeleteAccount(ctx, token)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	// Failed sign-ins are tracked by email, which is personal data too.
+	if err := app.logins.account.Reset(ctx, normalizeEmail(user.Email)); err != nil {
+		app.logger.Errorw("error resetting failed logins", "user", user.ID, "error", err)
+	}
+
+	app.logger.Infow("account deleted", "user", user.ID)
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
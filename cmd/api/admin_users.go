@@ -0,0 +1,442 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+const accountCtx userKey = "account"
+
+// SetUserRolePayload represents the payload for assigning a role to a user.
+//
+//	@Role	string	"Name of the role"	validate:"required,max=100"
+type SetUserRolePayload struct {
+	Role string `json:"role" validate:"required,max=100"`
+}
+
+// BanUserPayload represents the payload for banning a user.
+//
+//	@Reason	string	"Why the user is banned"	validate:"max=255"
+type BanUserPayload struct {
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+// GetUsers godoc
+//
+//	@Summary		Fetches users
+//	@Description	Fetches users of any status, optionally searching by email or username
+//	@Tags			admin
+//	@Produce		json
+//	@Param			search	query		string	false	"Part of the email or username"
+//	@Param			role	query		string	false	"Role name"
+//	@Param			status	query		string	false	"Account status (active|inactive|banned)"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Param			sort	query		string	false	"Sort order by sign-up date (asc|desc)"
+//	@Success		200		{object}	store.PaginatedUsersResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users [get]
+func (app *application) getUsersHandler(w http.ResponseWriter, r *http.Request) {
+	pq := store.PaginatedUsersQuery{
+		Limit:  50,
+		Offset: 0,
+		Sort:   "desc",
+	}
+
+	pq, err := pq.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(pq); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	users, total, err := app.store.Users.GetList(r.Context(), pq)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	response := store.PaginatedUsersResponse{
+		Data:  users,
+		Total: total,
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetAccount godoc
+//
+//	@Summary		Fetches a user
+//	@Description	Fetches a user of any status by ID
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		200		{object}	store.User
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID} [get]
+func (app *application) getAccountHandler(w http.ResponseWriter, r *http.Request) {
+	account := getAccountFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, account); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// SetUserRole godoc
+//
+//	@Summary		Assigns a role to a user
+//	@Description	Replaces the role of a user. Admins cannot change their own role, nor the role of a user
+//	@Description	whose current or new role grants permissions they lack.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int					true	"User ID"
+//	@Param			payload	body		SetUserRolePayload	true	"Role"
+//	@Success		200		{object}	store.User
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID}/role [put]
+func (app *application) setUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	account := getAccountFromCtx(r)
+
+	if account.ID == getUserFromCtx(r).ID {
+		app.badRequestResponse(w, r, fmt.Errorf("you cannot change your own role"))
+		return
+	}
+
+	var payload SetUserRolePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	role, err := app.store.Roles.GetByName(ctx, payload.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.badRequestResponse(w, r, fmt.Errorf("unknown role %q", payload.Role))
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	// Neither the current nor the new role of the user may grant more than
+	// the admin holds.
+	for _, roleID := range []int64{account.Role.ID, role.ID} {
+		if !app.outranks(w, r, roleID) {
+			return
+		}
+	}
+
+	if err := app.store.Users.SetRole(ctx, account.ID, payload.Role); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.badRequestResponse(w, r, fmt.Errorf("unknown role %q", payload.Role))
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Infow("user role changed", "user", account.ID, "role", payload.Role, "by", getUserFromCtx(r).ID)
+
+	account, err = app.store.Users.GetAccount(ctx, account.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, account); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// BanUser godoc
+//
+//	@Summary		Bans a user
+//	@Description	Blocks a user from signing in and ends every session of the user. Users whose role grants
+//	@Description	permissions the admin lacks cannot be banned.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int				true	"User ID"
+//	@Param			payload	body		BanUserPayload	true	"Ban reason"
+//	@Success		204		{string}	string			"User banned"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID}/ban [post]
+func (app *application) banUserHandler(w http.ResponseWriter, r *http.Request) {
+	account := getAccountFromCtx(r)
+
+	if account.ID == getUserFromCtx(r).ID {
+		app.badRequestResponse(w, r, fmt.Errorf("you cannot ban yourself"))
+		return
+	}
+
+	if !app.outranks(w, r, account.Role.ID) {
+		return
+	}
+
+	var payload BanUserPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Users.Ban(r.Context(), account.ID, payload.Reason); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Infow("user banned", "user", account.ID, "by", getUserFromCtx(r).ID)
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// UnbanUser godoc
+//
+//	@Summary		Unbans a user
+//	@Description	Lifts the ban of a user
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userID	path		int		true	"User ID"
+//	@Success		204		{string}	string	"User unbanned"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID}/ban [delete]
+func (app *application) unbanUserHandler(w http.ResponseWriter, r *http.Request) {
+	account := getAccountFromCtx(r)
+
+	if !app.outranks(w, r, account.Role.ID) {
+		return
+	}
+
+	if err := app.store.Users.Unban(r.Context(), account.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Infow("user unbanned", "user", account.ID, "by", getUserFromCtx(r).ID)
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// DeleteUser godoc
+//
+//	@Summary		Deletes a user
+//	@Description	Deletes a user. Their orders, tickets and payments are kept without a link to the account.
+//	@Description	Users whose role grants permissions the admin lacks cannot be deleted.
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userID	path		int		true	"User ID"
+//	@Success		204		{string}	string	"User deleted"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID} [delete]
+func (app *application) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	account := getAccountFromCtx(r)
+
+	if account.ID == getUserFromCtx(r).ID {
+		app.badRequestResponse(w, r, fmt.Errorf("you cannot delete yourself"))
+		return
+	}
+
+	if !app.outranks(w, r, account.Role.ID) {
+		return
+	}
+
+	if err := app.store.Users.Delete(r.Context(), account.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.logger.Infow("user deleted", "user", account.ID, "by", getUserFromCtx(r).ID)
+
+	if err := writeJSON(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetUserTickets godoc
+//
+//	@Summary		Fetches the tickets of a user
+//	@Description	Fetches every ticket bought by a user
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		200		{array}		store.Ticket
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID}/tickets [get]
+func (app *application) getUserTicketsHandler(w http.ResponseWriter, r *http.Request) {
+	account := getAccountFromCtx(r)
+
+	tickets, err := app.store.Tickets.GetByUserID(r.Context(), account.ID)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			app.internalServerError(w, r, err)
+			return
+		}
+		tickets = []store.Ticket{}
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, tickets); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetUserPayments godoc
+//
+//	@Summary		Fetches the payments of a user
+//	@Description	Fetches every payment attempt for the orders of a user, newest first
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		200		{array}		store.Payment
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID}/payments [get]
+func (app *application) getUserPaymentsHandler(w http.ResponseWriter, r *http.Request) {
+	account := getAccountFromCtx(r)
+
+	payments, err := app.store.Payments.GetByUserID(r.Context(), account.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, payments); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// outranks reports whether the current user holds every permission of the
+// role, so that managing users never lets anyone act on or hand out more
+// privileges than their own. Otherwise it responds with 403 itself.
+func (app *application) outranks(w http.ResponseWriter, r *http.Request, roleID int64) bool {
+	includes, err := app.store.Roles.Includes(r.Context(), getUserFromCtx(r).Role.ID, roleID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return false
+	}
+
+	if !includes {
+		app.forbiddenErrorResponse(w, r)
+		return false
+	}
+
+	return true
+}
+
+// accountContextMiddleware loads the user named in the URL whatever the status
+// of the account, so that admins can manage inactive and banned users too.
+func (app *application) accountContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idParam := chi.URLParam(r, "userID")
+		id, err := strconv.ParseInt(idParam, 10, 64)
+		if err != nil {
+			app.badRequestResponse(w, r, fmt.Errorf("must provide a correct id"))
+			return
+		}
+		ctx := r.Context()
+
+		account, err := app.store.Users.GetAccount(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, accountCtx, account)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getAccountFromCtx(r *http.Request) *store.User {
+	account, _ := r.Context().Value(accountCtx).(*store.User)
+	return account
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -16,6 +18,8 @@ import (
 	"github.com/k5sha/Tikceto/docs"
 	"github.com/k5sha/Tikceto/internal/auth"
 	"github.com/k5sha/Tikceto/internal/env"
+	"github.com/k5sha/Tikceto/internal/eticket"
+	"github.com/k5sha/Tikceto/internal/lockout"
 	"github.com/k5sha/Tikceto/internal/mailer"
 	"github.com/k5sha/Tikceto/internal/payment"
 	"github.com/k5sha/Tikceto/internal/s3"
@@ -28,10 +32,14 @@ type application struct {
 	config        config
 	store         store.Storage
 	authenticator auth.Authenticator
+	eticket       *eticket.Signer
 	mailer        mailer.Client
 	payment       payment.Client
 	logger        *zap.SugaredLogger
 	s3            s3.Client
+
+	logins          *loginLimiter
+	reconcileReport atomic.Pointer[reconcileReport]
 }
 
 type config struct {
@@ -40,10 +48,15 @@ type config struct {
 	auth        authConfig
 	mail        mailConfig
 	payment     payConfig
+	tickets     ticketsConfig
+	sessions    sessionsConfig
 	frontendURL string
 	env         string
 	db          dbConfig
 	s3          s3Config
+	// trustedProxies are the addresses of the reverse proxies in front of
+	// the API, the only peers whose forwarding headers are believed.
+	trustedProxies []netip.Prefix
 }
 
 type dbConfig struct {
@@ -54,8 +67,25 @@ type dbConfig struct {
 }
 
 type authConfig struct {
-	basic basicConfig
-	token tokenConfig
+	basic                 basicConfig
+	token                 tokenConfig
+	twoFactor             twoFactorConfig
+	lockout               lockoutConfig
+	passwordResetExp      time.Duration
+	accountDeletionExp    time.Duration
+	activationGrace       time.Duration
+	inactivePurgeInterval time.Duration
+}
+
+type lockoutConfig struct {
+	backend string
+	account lockout.Policy
+	ip      lockout.Policy
+}
+
+type twoFactorConfig struct {
+	issuer       string
+	challengeExp time.Duration
 }
 
 type basicConfig struct {
@@ -64,23 +94,62 @@ type basicConfig struct {
 }
 
 type tokenConfig struct {
-	secret string
-	exp    time.Duration
-	iss    string
+	algorithm     string
+	secret        string
+	exp           time.Duration
+	refreshExp    time.Duration
+	iss           string
+	keyRotation   time.Duration
+	keyPrepublish time.Duration
+	keyRefresh    time.Duration
 }
 
 type mailConfig struct {
 	exp       time.Duration
 	fromEmail string
 	smtp      smtpConfig
+	outbox    outboxConfig
+}
+
+type outboxConfig struct {
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
 }
 
 type payConfig struct {
-	pubKey      string
-	privateKey  string
-	frontendURL string
-	serverURL   string
+	provider          string
+	checkoutURL       string
+	apiURL            string
+	pubKey            string
+	privateKey        string
+	frontendURL       string
+	serverURL         string
+	reconcileInterval time.Duration
+	reconcileAfter    time.Duration
+	reconcileWindow   time.Duration
+}
+
+type ticketsConfig struct {
+	holdExp           time.Duration
+	holdSweepInterval time.Duration
+	cancelCutoff      time.Duration
+	refundPolicy      refundPolicy
+	codeSecret        string
+	checkinOpens      time.Duration
+	checkinCloses     time.Duration
 }
+
+type sessionsConfig struct {
+	cleaningBuffer time.Duration
+	// timeZone is where the cinema is; schedules are planned in its local
+	// time.
+	timeZone string
+	location *time.Location
+}
+
 type smtpConfig struct {
 	username string
 	password string
@@ -114,11 +183,15 @@ func (app *application) mount() *chi.Mux {
 	}))
 
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(app.realIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
 
+	if _, ok := app.authenticator.(auth.KeySource); ok {
+		r.Get("/.well-known/jwks.json", app.getJWKSHandler)
+	}
+
 	r.Route("/v1", func(r chi.Router) {
 		r.Get("/health", app.healthCheckHandler)
 
@@ -129,7 +202,7 @@ func (app *application) mount() *chi.Mux {
 		}
 
 		r.Route("/rooms", func(r chi.Router) {
-			r.With(app.AuthTokenMiddleware()).Post("/", app.checkPermissions("admin", app.createRoomHandler))
+			r.With(app.AuthTokenMiddleware()).Post("/", app.requirePermission("rooms:write", app.createRoomHandler))
 			r.Get("/", app.getRoomsHandler)
 			r.Route("/{roomID}", func(r chi.Router) {
 				r.Use(app.roomsContextMiddleware)
@@ -139,15 +212,15 @@ func (app *application) mount() *chi.Mux {
 				r.Group(func(r chi.Router) {
 					r.Use(app.AuthTokenMiddleware())
 
-					r.Delete("/", app.checkPermissions("admin", app.deleteRoomHandler))
-					r.Patch("/", app.checkPermissions("admin", app.updateRoomHandler))
+					r.Delete("/", app.requirePermission("rooms:write", app.deleteRoomHandler))
+					r.Patch("/", app.requirePermission("rooms:write", app.updateRoomHandler))
 				})
 
 			})
 		})
 
 		r.Route("/movies", func(r chi.Router) {
-			r.With(app.AuthTokenMiddleware()).Post("/", app.checkPermissions("admin", app.createMovieHandler))
+			r.With(app.AuthTokenMiddleware()).Post("/", app.requirePermission("movies:write", app.createMovieHandler))
 
 			r.Get("/", app.getMoviesHandler)
 
@@ -158,16 +231,19 @@ func (app *application) mount() *chi.Mux {
 
 				r.Group(func(r chi.Router) {
 					r.Use(app.AuthTokenMiddleware())
-					r.Delete("/", app.checkPermissions("admin", app.deleteMovieHandler))
-					r.Patch("/", app.checkPermissions("admin", app.updateMovieHandler))
+					r.Delete("/", app.requirePermission("movies:write", app.deleteMovieHandler))
+					r.Patch("/", app.requirePermission("movies:write", app.updateMovieHandler))
 				})
 
 			})
 		})
 
 		r.Route("/sessions", func(r chi.Router) {
-			r.With(app.AuthTokenMiddleware()).Post("/", app.checkPermissions("admin", app.createSessionHandler))
+			r.With(app.AuthTokenMiddleware()).Post("/", app.requirePermission("sessions:write", app.createSessionHandler))
+			r.With(app.AuthTokenMiddleware()).Post("/schedule", app.requirePermission("sessions:write", app.createScheduleHandler))
+			r.With(app.AuthTokenMiddleware()).Post("/schedule/clone", app.requirePermission("sessions:write", app.cloneScheduleHandler))
 
+			r.Get("/", app.getSessionsHandler)
 			r.Get("/movie/{movieID}", app.getSessionsByMovieHandler)
 
 			r.Route("/{sessionID}", func(r chi.Router) {
@@ -177,15 +253,17 @@ func (app *application) mount() *chi.Mux {
 
 				r.Group(func(r chi.Router) {
 					r.Use(app.AuthTokenMiddleware())
-					r.Delete("/", app.checkPermissions("admin", app.deleteSessionHandler))
-					r.Patch("/", app.checkPermissions("admin", app.updateSessionHandler))
+					r.Delete("/", app.requirePermission("sessions:write", app.deleteSessionHandler))
+					r.Patch("/", app.requirePermission("sessions:write", app.updateSessionHandler))
+					r.Post("/cancel", app.requirePermission("sessions:write", app.cancelSessionHandler))
+					r.Put("/prices", app.requirePermission("sessions:write", app.setSessionPricesHandler))
 				})
 
 			})
 		})
 
 		r.Route("/seats", func(r chi.Router) {
-			r.With(app.AuthTokenMiddleware()).Post("/", app.checkPermissions("admin", app.createSeatHandler))
+			r.With(app.AuthTokenMiddleware()).Post("/", app.requirePermission("seats:write", app.createSeatHandler))
 			r.Get("/session/{sessionID}", app.getSeatsBySessionHandler)
 
 			r.Route("/{seatID}", func(r chi.Router) {
@@ -194,8 +272,8 @@ func (app *application) mount() *chi.Mux {
 				r.Get("/", app.getSeatHandler)
 				r.Group(func(r chi.Router) {
 					r.Use(app.AuthTokenMiddleware())
-					r.Delete("/", app.checkPermissions("admin", app.deleteSeatHandler))
-					r.Patch("/", app.checkPermissions("admin", app.updateSeatHandler))
+					r.Delete("/", app.requirePermission("seats:write", app.deleteSeatHandler))
+					r.Patch("/", app.requirePermission("seats:write", app.updateSeatHandler))
 				})
 
 			})
@@ -204,36 +282,108 @@ func (app *application) mount() *chi.Mux {
 		r.Route("/tickets", func(r chi.Router) {
 			r.Group(func(r chi.Router) {
 				r.Use(app.AuthTokenMiddleware())
-				r.Post("/", app.checkPermissions("admin", app.createTicketHandler))
+				r.Post("/", app.requirePermission("tickets:write", app.createTicketHandler))
 				r.Get("/my", app.getMyTicketsHandler)
-				r.Get("/session/{sessionID}/seat/{seatID}", app.checkPermissions("admin", app.getTicketBySessionAndSeatHandler))
+				r.Get("/session/{sessionID}/seat/{seatID}", app.requirePermission("tickets:read", app.getTicketBySessionAndSeatHandler))
 			})
 			r.Route("/{ticketID}", func(r chi.Router) {
 				r.Use(app.ticketContextMiddleware)
 
 				r.Group(func(r chi.Router) {
 					r.Use(app.AuthTokenMiddleware())
-					r.Get("/", app.checkPermissions("admin", app.getTicketHandler))
-					r.Delete("/", app.checkPermissions("admin", app.deleteTicketHandler))
-					r.Patch("/", app.checkPermissions("admin", app.updateTicketHandler))
+					r.Get("/", app.requirePermission("tickets:read", app.getTicketHandler))
+					r.Delete("/", app.requirePermission("tickets:write", app.deleteTicketHandler))
+					r.Patch("/", app.requirePermission("tickets:write", app.updateTicketHandler))
+					r.Post("/cancel", app.cancelTicketHandler)
+					r.Get("/qr", app.getTicketQRHandler)
+					r.Get("/pdf", app.getTicketPDFHandler)
 				})
 
 			})
 		})
 
+		r.With(app.AuthTokenMiddleware()).Post("/checkin", app.requirePermission("tickets:checkin", app.checkinHandler))
+
+		r.Route("/orders", func(r chi.Router) {
+			r.With(app.AuthTokenMiddleware()).Get("/{orderID}", app.getOrderHandler)
+		})
+
 		r.Route("/payments", func(r chi.Router) {
 			r.With(app.AuthTokenMiddleware()).Post("/create", app.createPaymentHandler)
 			r.Post("/validate", app.validatePaymentHandler)
+
+			if checkout, ok := app.payment.(payment.Checkout); ok {
+				r.Mount("/"+app.payment.Name(), checkout.Handler())
+			}
+
+			r.Group(func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware())
+				r.Get("/", app.requirePermission("payments:read", app.getPaymentsHandler))
+				r.Get("/reconciliation", app.requirePermission("payments:read", app.getReconciliationReportHandler))
+				r.Get("/{paymentID}", app.requirePermission("payments:read", app.getPaymentHandler))
+			})
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware())
+
+			r.Route("/users", func(r chi.Router) {
+				r.Get("/", app.requirePermission("users:manage", app.getUsersHandler))
+
+				r.Route("/{userID}", func(r chi.Router) {
+					r.Use(app.accountContextMiddleware)
+
+					r.Get("/", app.requirePermission("users:manage", app.getAccountHandler))
+					r.Delete("/", app.requirePermission("users:manage", app.deleteUserHandler))
+					r.Put("/role", app.requirePermission("users:manage", app.setUserRoleHandler))
+					r.Post("/ban", app.requirePermission("users:manage", app.banUserHandler))
+					r.Delete("/ban", app.requirePermission("users:manage", app.unbanUserHandler))
+					r.Post("/unlock", app.requirePermission("users:manage", app.unlockUserHandler))
+					r.Get("/tickets", app.requirePermission("users:manage", app.getUserTicketsHandler))
+					r.Get("/payments", app.requirePermission("users:manage", app.getUserPaymentsHandler))
+				})
+			})
+
+			r.Get("/permissions", app.requirePermission("roles:manage", app.getPermissionsHandler))
+
+			r.Route("/roles", func(r chi.Router) {
+				r.Get("/", app.requirePermission("roles:manage", app.getRolesHandler))
+				r.Post("/", app.requirePermission("roles:manage", app.createRoleHandler))
+
+				r.Route("/{roleID}", func(r chi.Router) {
+					r.Use(app.rolesContextMiddleware)
+
+					r.Get("/", app.requirePermission("roles:manage", app.getRoleHandler))
+					r.Patch("/", app.requirePermission("roles:manage", app.updateRoleHandler))
+					r.Delete("/", app.requirePermission("roles:manage", app.deleteRoleHandler))
+					r.Put("/permissions", app.requirePermission("roles:manage", app.setRolePermissionsHandler))
+				})
+			})
 		})
 
 		r.Route("/users", func(r chi.Router) {
-			r.With(app.AuthTokenMiddleware()).Get("/me", app.getCurrentUserHandler)
+			r.Route("/me", func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware())
+				r.Get("/", app.getCurrentUserHandler)
+				r.Delete("/", app.requestAccountDeletionHandler)
+				r.Get("/export", app.exportUserDataHandler)
+				r.Post("/2fa", app.enrollTwoFactorHandler)
+				r.Delete("/2fa", app.disableTwoFactorHandler)
+				r.Post("/2fa/verify", app.enableTwoFactorHandler)
+			})
 			r.Put("/activate/{token}", app.activateUserHandler)
+			r.Put("/delete/{token}", app.confirmAccountDeletionHandler)
 		})
 
 		r.Route("/authentication", func(r chi.Router) {
 			r.Post("/user", app.registerUserHandler)
+			r.Post("/user/activation", app.resendActivationHandler)
 			r.Post("/token", app.createTokenHandler)
+			r.Post("/token/2fa", app.createTwoFactorTokenHandler)
+			r.Post("/refresh", app.refreshTokenHandler)
+			r.With(app.AuthTokenMiddleware()).Post("/logout", app.logoutHandler)
+			r.Post("/password/forgot", app.forgotPasswordHandler)
+			r.Put("/password/reset/{token}", app.resetPasswordHandler)
 		})
 	})
 	return r
@@ -255,6 +405,17 @@ func (app *application) run(mux *chi.Mux) error {
 		IdleTimeout:  time.Minute,
 	}
 
+	// Background workers
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go app.releaseExpiredHolds(ctx)
+	go app.reconcilePayments(ctx)
+	go app.deliverMail(ctx)
+	go app.rotateSigningKeys(ctx)
+	go app.purgeInactiveUsers(ctx)
+	go app.pruneLoginAttempts(ctx)
+
 	// Graceful shutdown
 	shutdown := make(chan error)
 
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -70,7 +72,13 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 	hash := sha256.Sum256([]byte(plainToken))
 	hashToken := hex.EncodeToString(hash[:])
 
-	err := app.store.Users.CreateAndInvite(ctx, user, hashToken, app.config.mail.exp)
+	mail, err := app.activationMail(user, plainToken)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	err = app.store.Users.CreateAndInvite(ctx, user, hashToken, app.config.mail.exp, mail)
 
 	if err != nil {
 		switch {
@@ -84,9 +92,90 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	app.logger.Infow("welcome email queued", "email", user.Email)
+	if err := app.jsonResponse(w, http.StatusCreated, user); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+}
+
+// ResendActivationPayload represents the payload for requesting a new
+// activation link.
+//
+//	@Email	string "The email address of the account" validate:"required,email,max=255"
+type ResendActivationPayload struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// resendActivationHandler godoc
+//
+//	@Summary		Resends the activation email
+//	@Description	Emails a new activation link if an account with the given email has not been activated yet.
+//	@Description	Links sent earlier stop working. The response is the same whether or not such an account exists.
+//	@Tags			authentication
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ResendActivationPayload	true	"Account email"
+//	@Success		202		{string}	string					"Activation link sent if the account exists"
+//	@Failure		400		{object}	error
+//	@Router			/authentication/user/activation [post]
+func (app *application) resendActivationHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ResendActivationPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(&payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// As with password resets, failures are only logged so that the response
+	// never tells whether an account with this email exists.
+	if err := app.resendActivation(r.Context(), payload.Email); err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.logger.Errorw("error resending activation email", "error", err)
+	}
+
+	if err := app.jsonResponse(w, http.StatusAccepted, "if an inactive account exists, a new activation link has been sent"); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+func (app *application) resendActivation(ctx context.Context, email string) error {
+	user, err := app.store.Users.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if user.IsActive {
+		return store.ErrNotFound
+	}
+
+	plainToken := uuid.New().String()
+
+	hash := sha256.Sum256([]byte(plainToken))
+	hashToken := hex.EncodeToString(hash[:])
+
+	mail, err := app.activationMail(user, plainToken)
+	if err != nil {
+		return err
+	}
+
+	if err := app.store.Users.RenewInvitation(ctx, user.ID, hashToken, app.config.mail.exp, mail); err != nil {
+		return err
+	}
+
+	app.logger.Infow("activation email queued", "email", user.Email)
+	return nil
+}
+
+func (app *application) activationMail(user *store.User, plainToken string) (*store.Mail, error) {
 	activationURL := fmt.Sprintf("%s/confirm/%s", app.config.frontendURL, plainToken)
 
-	isProdEnv := app.config.env == "production"
 	vars := struct {
 		Username      string
 		ActivationURL string
@@ -95,25 +184,32 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		ActivationURL: activationURL,
 	}
 
-	err = app.mailer.Send(mailer.UserWelcomeTemplate, user.Username, user.Email, vars, !isProdEnv)
-	if err != nil {
-		app.logger.Errorw("error sending welcome email", "error", err)
+	return app.newMail(mailer.UserWelcomeTemplate, user.Username, user.Email, vars)
+}
+
+// purgeInactiveUsers periodically removes accounts that were never activated
+// and whose last invitation expired more than the grace period ago, freeing
+// their emails and usernames. It stops when ctx is cancelled.
+func (app *application) purgeInactiveUsers(ctx context.Context) {
+	ticker := time.NewTicker(app.config.auth.inactivePurgeInterval)
+	defer ticker.Stop()
 
-		delCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-		defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := app.store.Users.DeleteInactive(ctx, time.Now().Add(-app.config.auth.activationGrace))
+			if err != nil {
+				app.logger.Errorw("error purging inactive users", "error", err)
+				continue
+			}
 
-		if err := app.store.Users.Delete(delCtx, user.ID); err != nil {
-			app.logger.Errorw("error deleting user", "error", err)
+			if deleted > 0 {
+				app.logger.Infow("purged inactive users", "count", deleted)
+			}
 		}
-		app.internalServerError(w, r, err)
-		return
 	}
-	app.logger.Infow("welcome email successfully sent", "email", user.Email)
-	if err := app.jsonResponse(w, http.StatusCreated, user); err != nil {
-		app.internalServerError(w, r, err)
-		return
-	}
-
 }
 
 // CreateUserTokenPayload represents the payload for creating a new user token.
@@ -128,14 +224,18 @@ type CreateUserTokenPayload struct {
 // createTokenHandler godoc
 //
 //	@Summary		Creates a token
-//	@Description	Creates a token for a user
+//	@Description	Creates a token for a user. Users with two-factor authentication get a challenge token instead,
+//	@Description	which is exchanged for the tokens at /authentication/token/2fa.
 //	@Tags			authentication
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		CreateUserTokenPayload	true	"User credentials"
-//	@Success		200		{string}	string					"Token"
+//	@Param			payload	body		CreateUserTokenPayload		true	"User credentials"
+//	@Success		200		{object}	TwoFactorChallengeResponse	"Second factor required"
+//	@Success		201		{object}	TokenResponse				"Tokens"
 //	@Failure		400		{object}	error
-//	@Failure		401		{object}	error
+//	@Failure		401		{object}	error	"invalid_credentials"
+//	@Failure		403		{object}	error	"account_not_activated or account_banned"
+//	@Failure		429		{object}	error	"account_locked or too_many_attempts"
 //	@Failure		500		{object}	error
 //	@Router			/authentication/token [post]
 func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Request) {
@@ -151,11 +251,16 @@ func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if !app.loginAllowed(w, r, payload.Email) {
+		return
+	}
+
 	user, err := app.store.Users.GetByEmail(r.Context(), payload.Email)
 	if err != nil {
 		switch {
 		case errors.Is(err, store.ErrNotFound):
-			app.unauthorizedErrorResponse(w, r, err)
+			app.loginFailed(r, payload.Email, nil)
+			app.invalidCredentialsResponse(w, r, err)
 		default:
 			app.internalServerError(w, r, err)
 		}
@@ -163,26 +268,232 @@ func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	if err := user.Password.Compare(payload.Password); err != nil {
-		app.unauthorizedErrorResponse(w, r, err)
+		app.loginFailed(r, payload.Email, user)
+		app.invalidCredentialsResponse(w, r, err)
 		return
 	}
 
-	claims := jwt.MapClaims{
-		"sub": user.ID,
-		"exp": time.Now().Add(app.config.auth.token.exp).Unix(),
-		"iat": time.Now().Unix(),
-		"nbf": time.Now().Unix(),
-		"iss": app.config.auth.token.iss,
-		"aud": app.config.auth.token.iss,
+	app.loginSucceeded(r.Context(), payload.Email)
+
+	// Only tell that the account is inactive to someone who knows its
+	// password.
+	if !user.IsActive {
+		app.accountNotActivatedResponse(w, r)
+		return
 	}
-	token, err := app.authenticator.GenerateToken(claims)
+
+	if user.BannedAt != nil {
+		app.accountBannedResponse(w, r)
+		return
+	}
+
+	ctx := r.Context()
+
+	twoFactor, err := app.store.TwoFactor.Get(ctx, user.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if twoFactor != nil && twoFactor.Enabled {
+		challenge, err := app.issueTwoFactorChallenge(user.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if err := app.jsonResponse(w, http.StatusOK, challenge); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	tokens, err := app.startSession(ctx, user.ID, false)
 	if err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
-	if err := app.jsonResponse(w, http.StatusCreated, token); err != nil {
+	if err := app.jsonResponse(w, http.StatusCreated, tokens); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 }
+
+// TokenResponse is a short-lived access token together with the refresh
+// token used to renew it.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// RefreshTokenPayload represents the payload for renewing an access token.
+//
+//	@RefreshToken	string "The refresh token" validate:"required,max=255"
+type RefreshTokenPayload struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=255"`
+}
+
+// refreshTokenHandler godoc
+//
+//	@Summary		Refreshes a token
+//	@Description	Exchanges a refresh token for a new access token and a new refresh token. Every refresh token can be
+//	@Description	used once; using it again revokes the whole session.
+//	@Tags			authentication
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		RefreshTokenPayload	true	"Refresh token"
+//	@Success		200		{object}	TokenResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error	"account_banned"
+//	@Failure		500		{object}	error
+//	@Router			/authentication/refresh [post]
+func (app *application) refreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshTokenPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(&payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	session, err := app.store.Tokens.Rotate(ctx, hashToken(payload.RefreshToken), hashToken(refreshToken), app.config.auth.token.refreshExp)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrTokenReused):
+			app.logger.Warnw("refresh token reused, session revoked", "error", err)
+			app.unauthorizedErrorResponse(w, r, err)
+		case errors.Is(err, store.ErrNotFound), errors.Is(err, store.ErrTokenExpired), errors.Is(err, store.ErrTokenRevoked):
+			app.unauthorizedErrorResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	user, err := app.getUser(ctx, session.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.unauthorizedErrorResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if user.BannedAt != nil {
+		app.accountBannedResponse(w, r)
+		return
+	}
+
+	tokens, err := app.issueTokens(session, refreshToken)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, tokens); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// logoutHandler godoc
+//
+//	@Summary		Logs out
+//	@Description	Revokes the current session, its access token and every refresh token issued for it
+//	@Tags			authentication
+//	@Produce		json
+//	@Success		204	{string}	string	"Logged out"
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/authentication/logout [post]
+func (app *application) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if err := app.store.Tokens.RevokeFamily(r.Context(), getAuthSessionFromCtx(r).ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// startSession opens a new login session for the user and issues its first
+// pair of tokens.
+func (app *application) startSession(ctx context.Context, userID int64, mfa bool) (*TokenResponse, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := app.store.Tokens.CreateFamily(ctx, userID, hashToken(refreshToken), app.config.auth.token.refreshExp, mfa)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.issueTokens(session, refreshToken)
+}
+
+// issueTokens signs an access token for the session. The session ID goes
+// into the "sid" claim so that the token stops working once the session is
+// revoked, and the "amr" claim tells whether a second factor was used.
+func (app *application) issueTokens(session *store.RefreshToken, refreshToken string) (*TokenResponse, error) {
+	now := time.Now()
+
+	amr := []string{"pwd"}
+	if session.MFA {
+		amr = append(amr, "otp")
+	}
+
+	claims := jwt.MapClaims{
+		"sub": session.UserID,
+		"sid": session.FamilyID,
+		"amr": amr,
+		"exp": now.Add(app.config.auth.token.exp).Unix(),
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"iss": app.config.auth.token.iss,
+		"aud": app.config.auth.token.iss,
+	}
+	token, err := app.authenticator.GenerateToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(app.config.auth.token.exp.Seconds()),
+	}, nil
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/k5sha/Tikceto/internal/mailer"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+// CancelSessionPayload represents the payload for cancelling a session.
+//
+//	@Reason	string	"Why the session is cancelled, shown to ticket holders"	validate:"max=255"
+type CancelSessionPayload struct {
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+// cancellationTicket is a ticket as listed in the cancellation email.
+type cancellationTicket struct {
+	Row      int64
+	Seat     int64
+	Refund   float64
+	Refunded bool
+}
+
+// CancelSession godoc
+//
+//	@Summary		Cancels a session
+//	@Description	Cancels a session instead of deleting it. Paid tickets are refunded in full in the background and
+//	@Description	their holders are emailed; the session disappears from the timetable but is kept for the record.
+//	@Description	Cancelling a cancelled session again retries the refunds that failed.
+//	@Tags			sessions
+//	@Accept			json
+//	@Produce		json
+//	@Param			sessionID	path		int						true	"Session ID"
+//	@Param			payload		body		CancelSessionPayload	true	"Cancellation reason"
+//	@Success		202			{object}	store.Session
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/sessions/{sessionID}/cancel [post]
+func (app *application) cancelSessionHandler(w http.ResponseWriter, r *http.Request) {
+	session := getSessionFromCtx(r)
+
+	var payload CancelSessionPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	notify := session.Status != store.SessionStatusCancelled
+
+	if notify {
+		if err := app.store.Sessions.Cancel(r.Context(), session, payload.Reason); err != nil {
+			switch {
+			case errors.Is(err, store.ErrInvalidTransition):
+				app.badRequestResponse(w, r, store.ErrSessionCancelled)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		app.logger.Infow("session cancelled", "session", session.ID, "by", getUserFromCtx(r).ID)
+	}
+
+	go app.refundCancelledSession(session, notify)
+
+	if err := app.jsonResponse(w, http.StatusAccepted, session); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// refundCancelledSession refunds every paid ticket of a cancelled session in
+// full and, with notify, emails the holders. It runs in the background of the
+// cancellation, so errors are only logged; tickets whose refund failed stay
+// confirmed until the cancellation is repeated.
+func (app *application) refundCancelledSession(session *store.Session, notify bool) {
+	ctx := context.Background()
+
+	tickets, err := app.store.Tickets.GetConfirmedBySession(ctx, session.ID)
+	if err != nil {
+		app.logger.Errorw("error fetching tickets of a cancelled session", "session", session.ID, "error", err)
+		return
+	}
+
+	payments := make(map[string]*store.Payment)
+	holders := make(map[int64][]cancellationTicket)
+	failed := 0
+
+	for i := range tickets {
+		ticket := &tickets[i]
+		item := cancellationTicket{Row: ticket.Seat.Row, Seat: ticket.Seat.Number, Refund: ticket.Price}
+
+		if err := app.refundCancelledTicket(ctx, ticket, payments); err != nil {
+			app.logger.Errorw("error refunding ticket of a cancelled session", "session", session.ID, "ticket", ticket.ID, "error", err)
+			failed++
+		} else {
+			item.Refunded = true
+		}
+
+		if ticket.UserID != nil {
+			holders[*ticket.UserID] = append(holders[*ticket.UserID], item)
+		}
+	}
+
+	app.logger.Infow("refunded cancelled session", "session", session.ID, "tickets", len(tickets), "failed", failed)
+
+	if !notify {
+		return
+	}
+
+	for userID, items := range holders {
+		if err := app.mailSessionCancellation(ctx, session, userID, items); err != nil {
+			app.logger.Errorw("error sending session cancellation", "session", session.ID, "user", userID, "error", err)
+		}
+	}
+}
+
+// refundCancelledTicket returns the full price of the ticket to its buyer.
+// payments caches the payment of every order seen so far.
+func (app *application) refundCancelledTicket(ctx context.Context, ticket *store.Ticket, payments map[string]*store.Payment) error {
+	if ticket.OrderID == nil {
+		return errors.New("the ticket was not paid online")
+	}
+
+	attempt, ok := payments[*ticket.OrderID]
+	if !ok {
+		var err error
+		attempt, err = app.store.Payments.GetByOrderID(ctx, *ticket.OrderID)
+		if err != nil {
+			return err
+		}
+		payments[*ticket.OrderID] = attempt
+	}
+
+	if attempt.Status != store.PaymentStatusConfirmed {
+		return fmt.Errorf("the payment for the ticket is %s", attempt.Status)
+	}
+
+	_, err := app.refund(ctx, attempt, &ticket.ID, ticket.Price, "session cancelled")
+	return err
+}
+
+func (app *application) mailSessionCancellation(ctx context.Context, session *store.Session, userID int64, tickets []cancellationTicket) error {
+	user, err := app.store.Users.GetByID(ctx, userID)
+	if err != nil {
+		// The holder may have deleted their account in the meantime.
+		if errors.Is(err, store.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	startTime, err := time.Parse(time.RFC3339, session.StartTime)
+	if err != nil {
+		return err
+	}
+
+	var reason string
+	if session.CancellationReason != nil {
+		reason = *session.CancellationReason
+	}
+
+	vars := struct {
+		Username  string
+		Movie     string
+		Room      string
+		StartTime string
+		Reason    string
+		Tickets   []cancellationTicket
+	}{
+		Username:  user.Username,
+		Movie:     session.Movie.Title,
+		Room:      session.Room.Name,
+		StartTime: startTime.In(app.config.sessions.location).Format("02.01.2006 15:04"),
+		Reason:    reason,
+		Tickets:   tickets,
+	}
+
+	return app.enqueueMail(ctx, mailer.SessionCancellationTemplate, user.Username, user.Email, vars)
+}
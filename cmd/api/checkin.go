@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/k5sha/Tikceto/internal/eticket"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+// GetTicketQR godoc
+//
+//	@Summary		Fetches the QR code of a ticket
+//	@Description	Renders the signed e-ticket code of a confirmed ticket of the current user as a PNG or SVG image
+//	@Tags			tickets
+//	@Produce		png
+//	@Produce		image/svg+xml
+//	@Param			ticketID	path		string	true	"Ticket ID"
+//	@Param			format		query		string	false	"Image format (png|svg)"
+//	@Success		200			{file}		binary
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/tickets/{ticketID}/qr [get]
+func (app *application) getTicketQRHandler(w http.ResponseWriter, r *http.Request) {
+	ticket := getTicketFromCtx(r)
+	user := getUserFromCtx(r)
+
+	if !ticket.OwnedBy(user.ID) {
+		app.notFoundResponse(w, r, store.ErrNotFound)
+		return
+	}
+
+	if ticket.Status != store.TicketStatusConfirmed {
+		app.badRequestResponse(w, r, fmt.Errorf("only confirmed tickets have a QR code"))
+		return
+	}
+
+	code := app.eticket.Sign(ticket.ID, ticket.SessionID)
+
+	var (
+		image       []byte
+		contentType string
+		err         error
+	)
+
+	switch r.URL.Query().Get("format") {
+	case "", "png":
+		image, err = eticket.PNG(code, 512)
+		contentType = "image/png"
+	case "svg":
+		image, err = eticket.SVG(code)
+		contentType = "image/svg+xml"
+	default:
+		app.badRequestResponse(w, r, fmt.Errorf("format must be png or svg"))
+		return
+	}
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(image)
+}
+
+// GetTicketPDF godoc
+//
+//	@Summary		Downloads a printable ticket
+//	@Description	Renders a confirmed ticket of the current user with its movie, seat and QR code as a PDF
+//	@Tags			tickets
+//	@Produce		application/pdf
+//	@Param			ticketID	path		string	true	"Ticket ID"
+//	@Success		200			{file}		binary
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/tickets/{ticketID}/pdf [get]
+func (app *application) getTicketPDFHandler(w http.ResponseWriter, r *http.Request) {
+	ticket := getTicketFromCtx(r)
+	user := getUserFromCtx(r)
+
+	if !ticket.OwnedBy(user.ID) {
+		app.notFoundResponse(w, r, store.ErrNotFound)
+		return
+	}
+
+	if ticket.Status != store.TicketStatusConfirmed {
+		app.badRequestResponse(w, r, fmt.Errorf("only confirmed tickets can be printed"))
+		return
+	}
+
+	ctx := r.Context()
+
+	startTime, err := time.Parse(time.RFC3339, ticket.Session.StartTime)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	movie, err := app.store.Movies.GetByID(ctx, ticket.Session.MovieID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	pdf, err := app.ticketPDF(ticket, movie, app.moviePoster(ctx, movie), startTime)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="ticket-%s.pdf"`, ticket.ID))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(pdf)
+}
+
+// ticketPDF renders a printable ticket showing the start time in the local
+// time of the cinema. The ticket must have its session and seat loaded.
+func (app *application) ticketPDF(ticket *store.Ticket, movie *store.Movie, poster []byte, startTime time.Time) ([]byte, error) {
+	return eticket.PDF(eticket.Ticket{
+		ID:        ticket.ID,
+		Code:      app.eticket.Sign(ticket.ID, ticket.SessionID),
+		Movie:     movie.Title,
+		Room:      ticket.Session.Room.Name,
+		Row:       ticket.Seat.Row,
+		Seat:      ticket.Seat.Number,
+		StartTime: startTime.In(app.config.sessions.location),
+		Price:     ticket.Price,
+		Currency:  "UAH",
+		Poster:    poster,
+	})
+}
+
+// moviePoster downloads the poster of a movie. Tickets are still printed
+// without it if it is unavailable.
+func (app *application) moviePoster(ctx context.Context, movie *store.Movie) []byte {
+	poster, err := app.s3.Download(ctx, movie.PosterUrl)
+	if err != nil {
+		app.logger.Warnw("error downloading movie poster", "movie", movie.ID, "error", err)
+		return nil
+	}
+	return poster
+}
+
+// CheckinPayload represents the payload for checking a ticket in.
+//
+//	@Code	string	"Code scanned from the ticket QR" validate:"required,max=255"
+type CheckinPayload struct {
+	Code string `json:"code" validate:"required,max=255"`
+}
+
+// CheckinResponse is what the usher screen shows after a successful check-in.
+type CheckinResponse struct {
+	TicketID  string `json:"ticket_id"`
+	SessionID int64  `json:"session_id"`
+	Movie     string `json:"movie"`
+	Room      string `json:"room"`
+	StartTime string `json:"start_time"`
+	Row       int64  `json:"row"`
+	Seat      int64  `json:"seat_number"`
+	UsedAt    string `json:"used_at"`
+}
+
+// Checkin godoc
+//
+//	@Summary		Checks a ticket in
+//	@Description	Verifies a scanned e-ticket code and marks the ticket as used. A ticket can be checked in only once
+//	@Description	and only while check-in for its session is open.
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CheckinPayload	true	"Check-in payload"
+//	@Success		200		{object}	CheckinResponse
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/checkin [post]
+func (app *application) checkinHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CheckinPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	claims, err := app.eticket.Verify(payload.Code)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	ticket, err := app.store.Tickets.GetByID(ctx, claims.TicketID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if ticket.SessionID != claims.SessionID {
+		app.badRequestResponse(w, r, eticket.ErrInvalidCode)
+		return
+	}
+
+	session, err := app.store.Sessions.GetByID(ctx, ticket.SessionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if session.Status == store.SessionStatusCancelled {
+		app.badRequestResponse(w, r, store.ErrSessionCancelled)
+		return
+	}
+
+	startTime, err := time.Parse(time.RFC3339, session.StartTime)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	if now.Before(startTime.Add(-app.config.tickets.checkinOpens)) {
+		app.badRequestResponse(w, r, fmt.Errorf("check-in opens %s before the session", app.config.tickets.checkinOpens))
+		return
+	}
+	if now.After(startTime.Add(app.config.tickets.checkinCloses)) {
+		app.badRequestResponse(w, r, fmt.Errorf("check-in for this session is closed"))
+		return
+	}
+
+	user := getUserFromCtx(r)
+
+	if err := app.store.Tickets.CheckIn(ctx, ticket, user.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		case errors.Is(err, store.ErrTicketUsed):
+			app.conflictResponse(w, r, fmt.Errorf("%w at %s", err, *ticket.UsedAt))
+		case errors.Is(err, store.ErrInvalidTransition):
+			app.badRequestResponse(w, r, fmt.Errorf("the ticket is %s", ticket.Status))
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	seat, err := app.store.Seats.GetByID(ctx, ticket.SeatID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	response := CheckinResponse{
+		TicketID:  ticket.ID,
+		SessionID: session.ID,
+		Movie:     session.Movie.Title,
+		Room:      session.Room.Name,
+		StartTime: session.StartTime,
+		Row:       seat.Row,
+		Seat:      seat.Number,
+		UsedAt:    *ticket.UsedAt,
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
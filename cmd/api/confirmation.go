@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k5sha/Tikceto/internal/eticket"
+	"github.com/k5sha/Tikceto/internal/mailer"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+// confirmationTicket is a ticket as listed in the confirmation email.
+type confirmationTicket struct {
+	Movie     string
+	Room      string
+	StartTime string
+	Row       int64
+	Seat      int64
+}
+
+// sendOrderConfirmation emails the buyer their tickets once the order has
+// been paid. It runs in the background of the payment callback, so errors are
+// only logged.
+func (app *application) sendOrderConfirmation(orderID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := app.mailOrderConfirmation(ctx, orderID); err != nil {
+		app.logger.Errorw("error sending order confirmation", "order", orderID, "error", err)
+	}
+}
+
+func (app *application) mailOrderConfirmation(ctx context.Context, orderID string) error {
+	order, err := app.store.Orders.GetByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	// The buyer may have deleted their account since placing the order.
+	if order.UserID == nil {
+		return nil
+	}
+
+	user, err := app.store.Users.GetByID(ctx, *order.UserID)
+	if err != nil {
+		return err
+	}
+
+	sessions := make(map[int64]*store.Session)
+	movies := make(map[int64]*store.Movie)
+	posters := make(map[int64][]byte)
+
+	var (
+		tickets     []confirmationTicket
+		attachments []mailer.Attachment
+		events      []eticket.Event
+	)
+
+	for i := range order.Tickets {
+		ticket := &order.Tickets[i]
+		if ticket.Status != store.TicketStatusConfirmed {
+			continue
+		}
+
+		session, seen := sessions[ticket.SessionID]
+		if !seen {
+			session, err = app.store.Sessions.GetByID(ctx, ticket.SessionID)
+			if err != nil {
+				return err
+			}
+			sessions[ticket.SessionID] = session
+		}
+		ticket.Session = *session
+
+		movie, ok := movies[session.MovieID]
+		if !ok {
+			movie, err = app.store.Movies.GetByID(ctx, session.MovieID)
+			if err != nil {
+				return err
+			}
+			movies[session.MovieID] = movie
+			posters[session.MovieID] = app.moviePoster(ctx, movie)
+		}
+
+		startTime, err := time.Parse(time.RFC3339, session.StartTime)
+		if err != nil {
+			return err
+		}
+
+		pdf, err := app.ticketPDF(ticket, movie, posters[session.MovieID], startTime)
+		if err != nil {
+			return err
+		}
+
+		attachments = append(attachments, mailer.Attachment{
+			Filename:    fmt.Sprintf("ticket-%s.pdf", ticket.ID),
+			ContentType: "application/pdf",
+			Data:        pdf,
+		})
+
+		if !seen {
+			events = append(events, eticket.Event{
+				UID:      fmt.Sprintf("order-%s-session-%d@tikceto", order.ID, session.ID),
+				Summary:  movie.Title,
+				Location: session.Room.Name,
+				Start:    startTime,
+				End:      startTime.Add(time.Duration(movie.Duration) * time.Minute),
+			})
+		}
+
+		tickets = append(tickets, confirmationTicket{
+			Movie:     movie.Title,
+			Room:      session.Room.Name,
+			StartTime: startTime.In(app.config.sessions.location).Format("02.01.2006 15:04"),
+			Row:       ticket.Seat.Row,
+			Seat:      ticket.Seat.Number,
+		})
+	}
+
+	if len(tickets) == 0 {
+		return nil
+	}
+
+	attachments = append(attachments, mailer.Attachment{
+		Filename:    "sessions.ics",
+		ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+		Data:        eticket.ICS(events...),
+	})
+
+	vars := struct {
+		Username string
+		OrderID  string
+		Amount   float64
+		Currency string
+		Tickets  []confirmationTicket
+	}{
+		Username: user.Username,
+		OrderID:  order.ID,
+		Amount:   order.Amount,
+		Currency: order.Currency,
+		Tickets:  tickets,
+	}
+
+	return app.enqueueMail(ctx, mailer.TicketConfirmationTemplate, user.Username, user.Email, vars, attachments...)
+}
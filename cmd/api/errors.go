@@ -1,7 +1,26 @@
 package main
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+// Error codes returned next to the error message where clients need to tell
+// failures apart.
+const (
+	errCodeInvalidCredentials     = "invalid_credentials"
+	errCodeAccountNotActivated    = "account_not_activated"
+	errCodeActivationTokenExpired = "activation_token_expired"
+	errCodeTwoFactorRequired      = "two_factor_required"
+	errCodeInvalidTwoFactorCode   = "invalid_two_factor_code"
+	errCodeAccountLocked          = "account_locked"
+	errCodeTooManyAttempts        = "too_many_attempts"
+	errCodeAccountBanned          = "account_banned"
+	errCodeSessionConflict        = "session_conflict"
 )
 
 func (app *application) internalServerError(w http.ResponseWriter, r *http.Request, err error) {
@@ -35,3 +54,73 @@ func (app *application) forbiddenErrorResponse(w http.ResponseWriter, r *http.Re
 	app.logger.Warnf("forbidden error", "method", r.Method, "url", r.URL.Path)
 	writeJSONError(w, http.StatusForbidden, "forbidden")
 }
+
+func (app *application) conflictResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Warnf("conflict error", "method", r.Method, "url", r.URL.Path, "err", err)
+	writeJSONError(w, http.StatusConflict, err.Error())
+}
+
+func (app *application) invalidCredentialsResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Warnf("invalid credentials", "method", r.Method, "url", r.URL.Path, "err", err)
+	writeJSONErrorCode(w, http.StatusUnauthorized, errCodeInvalidCredentials, "invalid email or password")
+}
+
+func (app *application) accountNotActivatedResponse(w http.ResponseWriter, r *http.Request) {
+	app.logger.Warnf("account not activated", "method", r.Method, "url", r.URL.Path)
+	writeJSONErrorCode(w, http.StatusForbidden, errCodeAccountNotActivated, "the account has not been activated yet")
+}
+
+func (app *application) accountBannedResponse(w http.ResponseWriter, r *http.Request) {
+	app.logger.Warnf("account banned", "method", r.Method, "url", r.URL.Path)
+	writeJSONErrorCode(w, http.StatusForbidden, errCodeAccountBanned, "the account has been banned")
+}
+
+func (app *application) activationTokenExpiredResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Warnf("activation token expired", "method", r.Method, "url", r.URL.Path, "err", err)
+	writeJSONErrorCode(w, http.StatusGone, errCodeActivationTokenExpired, "the activation link has expired, request a new one")
+}
+
+func (app *application) twoFactorRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	app.logger.Warnf("two-factor authentication required", "method", r.Method, "url", r.URL.Path)
+	writeJSONErrorCode(w, http.StatusForbidden, errCodeTwoFactorRequired, "this action requires signing in with two-factor authentication")
+}
+
+func (app *application) invalidTwoFactorCodeResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.Warnf("invalid two-factor code", "method", r.Method, "url", r.URL.Path, "err", err)
+	writeJSONErrorCode(w, http.StatusUnauthorized, errCodeInvalidTwoFactorCode, "invalid two-factor code")
+}
+
+func (app *application) tooManyAttemptsResponse(w http.ResponseWriter, r *http.Request, code string, retryAfter time.Duration) {
+	app.logger.Warnf("too many attempts", "method", r.Method, "url", r.URL.Path, "code", code, "retry_after", retryAfter)
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	writeJSONErrorCode(w, http.StatusTooManyRequests, code, "too many failed attempts, try again later")
+}
+
+// sessionConflictResponse reports that a room is already booked, together with
+// the session occupying it if it is known.
+func (app *application) sessionConflictResponse(w http.ResponseWriter, r *http.Request, err error, conflict *store.Session) {
+	app.logger.Warnf("session conflict", "method", r.Method, "url", r.URL.Path, "err", err)
+
+	type envelope struct {
+		Error    string         `json:"error"`
+		Code     string         `json:"code"`
+		Conflict *store.Session `json:"conflict,omitempty"`
+	}
+
+	writeJSON(w, http.StatusConflict, envelope{Error: err.Error(), Code: errCodeSessionConflict, Conflict: conflict})
+}
+
+// scheduleConflictResponse reports that some sessions of a schedule overlap
+// other sessions, with the whole schedule so that they can be found.
+func (app *application) scheduleConflictResponse(w http.ResponseWriter, r *http.Request, err error, schedule ScheduleResponse) {
+	app.logger.Warnf("schedule conflict", "method", r.Method, "url", r.URL.Path, "conflicts", schedule.Conflicts)
+
+	type envelope struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+		ScheduleResponse
+	}
+
+	writeJSON(w, http.StatusConflict, envelope{Error: err.Error(), Code: errCodeSessionConflict, ScheduleResponse: schedule})
+}
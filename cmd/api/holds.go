@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// releaseExpiredHolds periodically frees seats whose holds ran out before the
+// buyer completed the payment. It stops when ctx is cancelled.
+func (app *application) releaseExpiredHolds(ctx context.Context) {
+	ticker := time.NewTicker(app.config.tickets.holdSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			released, err := app.store.Holds.ReleaseExpired(ctx)
+			if err != nil {
+				app.logger.Errorw("error releasing expired seat holds", "error", err)
+				continue
+			}
+
+			if released > 0 {
+				app.logger.Infow("released expired seat holds", "count", released)
+			}
+		}
+	}
+}
@@ -52,6 +52,17 @@ func writeJSONError(w http.ResponseWriter, status int, message string) error {
 	return writeJSON(w, status, envelope{Error: message})
 }
 
+// writeJSONErrorCode writes an error together with a stable code that clients
+// can branch on instead of matching the message.
+func writeJSONErrorCode(w http.ResponseWriter, status int, code, message string) error {
+	type envelope struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+
+	return writeJSON(w, status, envelope{Error: message, Code: code})
+}
+
 func (app *application) jsonResponse(w http.ResponseWriter, status int, data any) error {
 	type envelope struct {
 		Data any `json:"data"`
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/k5sha/Tikceto/internal/auth"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+// rotateSigningKeys periodically reloads the token signing keys shared by
+// every API instance and rotates them when the current key gets too old. It
+// stops when ctx is cancelled.
+func (app *application) rotateSigningKeys(ctx context.Context) {
+	ticker := time.NewTicker(app.config.auth.token.keyRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := app.syncSigningKeys(ctx); err != nil {
+				app.logger.Errorw("error syncing signing keys", "error", err)
+			}
+		}
+	}
+}
+
+// syncSigningKeys loads the stored signing keys into the authenticator. A new
+// key is generated when there is none yet, when the newest one is older than
+// the rotation interval or when the signing algorithm was changed. New keys
+// are published in the key set ahead of their first use, so that services
+// caching it learn about a key before they see tokens signed with it. Keys are
+// dropped once every token they signed has expired.
+func (app *application) syncSigningKeys(ctx context.Context) error {
+	keySet, ok := app.authenticator.(*auth.KeySetAuthenticator)
+	if !ok {
+		return nil
+	}
+
+	cfg := app.config.auth.token
+	now := time.Now()
+
+	if _, err := app.store.SigningKeys.DeleteRetired(ctx, now.Add(-cfg.exp)); err != nil {
+		return err
+	}
+
+	stored, err := app.store.SigningKeys.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*auth.Key, 0, len(stored)+1)
+	for _, k := range stored {
+		key, err := auth.ParseKey(k.ID, k.Algorithm, k.PrivateKey, k.NotBefore)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	var latest *auth.Key
+	if len(keys) > 0 {
+		latest = keys[len(keys)-1]
+	}
+
+	if latest == nil || latest.Algorithm != cfg.algorithm || now.Sub(latest.NotBefore) >= cfg.keyRotation {
+		notBefore := now.Add(cfg.keyPrepublish)
+		if signingKey(keys, now) == nil {
+			notBefore = now
+		}
+
+		key, err := auth.GenerateKey(cfg.algorithm, notBefore)
+		if err != nil {
+			return err
+		}
+
+		der, err := key.MarshalPrivateKey()
+		if err != nil {
+			return err
+		}
+
+		err = app.store.SigningKeys.Create(ctx, &store.SigningKey{
+			ID:         key.ID,
+			Algorithm:  key.Algorithm,
+			PrivateKey: der,
+			NotBefore:  key.NotBefore,
+		})
+		if err != nil {
+			return err
+		}
+
+		app.logger.Infow("created signing key", "kid", key.ID, "algorithm", key.Algorithm, "not_before", key.NotBefore)
+		keys = append(keys, key)
+	}
+
+	keySet.SetKeys(signingKey(keys, now), keys)
+
+	return nil
+}
+
+// signingKey returns the newest key already in use at the given time.
+func signingKey(keys []*auth.Key, at time.Time) *auth.Key {
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !keys[i].NotBefore.After(at) {
+			return keys[i]
+		}
+	}
+	return nil
+}
+
+// getJWKSHandler returns the public keys that verify access tokens as a JSON
+// Web Key Set, so that other services can check tokens without holding the
+// signing keys.
+func (app *application) getJWKSHandler(w http.ResponseWriter, r *http.Request) {
+	source, ok := app.authenticator.(auth.KeySource)
+	if !ok {
+		app.notFoundResponse(w, r, fmt.Errorf("tokens are not signed with public keys"))
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(app.config.auth.token.keyRefresh.Seconds())))
+
+	if err := writeJSON(w, http.StatusOK, source.JWKS()); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
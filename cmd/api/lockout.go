@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k5sha/Tikceto/internal/lockout"
+	"github.com/k5sha/Tikceto/internal/mailer"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+// loginLimiter tracks failed sign-ins per account, per client IP and per
+// account at the two-factor step.
+type loginLimiter struct {
+	backend   lockout.Backend
+	account   *lockout.Limiter
+	ip        *lockout.Limiter
+	twoFactor *lockout.Limiter
+}
+
+func newLoginLimiter(backend lockout.Backend, cfg lockoutConfig) *loginLimiter {
+	return &loginLimiter{
+		backend:   backend,
+		account:   lockout.NewLimiter(backend, "account:", cfg.account),
+		ip:        lockout.NewLimiter(backend, "ip:", cfg.ip),
+		twoFactor: lockout.NewLimiter(backend, "2fa:", cfg.account),
+	}
+}
+
+// loginAllowed writes a 429 response and returns false if the client IP or
+// the account is locked out.
+func (app *application) loginAllowed(w http.ResponseWriter, r *http.Request, email string) bool {
+	ctx := r.Context()
+
+	retryAfter, err := app.logins.ip.RetryAfter(ctx, clientIP(r))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return false
+	}
+
+	if retryAfter > 0 {
+		app.tooManyAttemptsResponse(w, r, errCodeTooManyAttempts, retryAfter)
+		return false
+	}
+
+	retryAfter, err = app.logins.account.RetryAfter(ctx, normalizeEmail(email))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return false
+	}
+
+	if retryAfter > 0 {
+		app.tooManyAttemptsResponse(w, r, errCodeAccountLocked, retryAfter)
+		return false
+	}
+
+	return true
+}
+
+// loginFailed records a failed sign-in. Failures are counted for unknown
+// emails as well, so that a lockout does not reveal whether an account
+// exists. When the failure locks out a known account its owner gets an email.
+func (app *application) loginFailed(r *http.Request, email string, user *store.User) {
+	ctx := r.Context()
+
+	if _, _, err := app.logins.ip.Fail(ctx, clientIP(r)); err != nil {
+		app.logger.Errorw("error recording failed login", "ip", clientIP(r), "error", err)
+	}
+
+	attempt, lockedOut, err := app.logins.account.Fail(ctx, normalizeEmail(email))
+	if err != nil {
+		app.logger.Errorw("error recording failed login", "email", email, "error", err)
+		return
+	}
+
+	if lockedOut && user != nil {
+		app.logger.Warnw("account locked out", "user", user.ID, "failures", attempt.Failures, "until", attempt.LockedUntil)
+		app.notifyLockout(ctx, user, attempt)
+	}
+}
+
+func (app *application) loginSucceeded(ctx context.Context, email string) {
+	if err := app.logins.account.Reset(ctx, normalizeEmail(email)); err != nil {
+		app.logger.Errorw("error resetting failed logins", "email", email, "error", err)
+	}
+}
+
+func (app *application) notifyLockout(ctx context.Context, user *store.User, attempt lockout.Attempt) {
+	vars := struct {
+		Username    string
+		Failures    int
+		LockedUntil string
+		ResetURL    string
+	}{
+		Username:    user.Username,
+		Failures:    attempt.Failures,
+		LockedUntil: attempt.LockedUntil.In(app.config.sessions.location).Format("02.01.2006 15:04"),
+		ResetURL:    fmt.Sprintf("%s/password/forgot", app.config.frontendURL),
+	}
+
+	if err := app.enqueueMail(ctx, mailer.AccountLockedTemplate, user.Username, user.Email, vars); err != nil {
+		app.logger.Errorw("error queueing lockout email", "user", user.ID, "error", err)
+	}
+}
+
+// unlockUserHandler godoc
+//
+//	@Summary		Unlocks a user
+//	@Description	Clears the failed sign-in attempts of a user, lifting a lockout
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userID	path		int		true	"User ID"
+//	@Success		204		{string}	string	"User unlocked"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID}/unlock [post]
+func (app *application) unlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := getAccountFromCtx(r)
+
+	ctx := r.Context()
+
+	if err := app.logins.account.Reset(ctx, normalizeEmail(user.Email)); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.logins.twoFactor.Reset(ctx, strconv.FormatInt(user.ID, 10)); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.logger.Infow("user unlocked", "user", user.ID, "by", getUserFromCtx(r).ID)
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// pruneLoginAttempts periodically forgets failed sign-ins that are too old
+// to count. It stops when ctx is cancelled.
+func (app *application) pruneLoginAttempts(ctx context.Context) {
+	window := app.config.auth.lockout.account.Window
+	if app.config.auth.lockout.ip.Window > window {
+		window = app.config.auth.lockout.ip.Window
+	}
+
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := app.logins.backend.DeleteStale(ctx, time.Now().Add(-window))
+			if err != nil {
+				app.logger.Errorw("error pruning login attempts", "error", err)
+				continue
+			}
+
+			if deleted > 0 {
+				app.logger.Infow("pruned login attempts", "count", deleted)
+			}
+		}
+	}
+}
+
+// clientIP returns the address of the client. Behind trusted proxies realIP
+// has already put it in place of the address of the proxy.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
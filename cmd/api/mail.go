@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/k5sha/Tikceto/internal/mailer"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+// mailDeliveryLease is how long a claimed email is reserved for the worker
+// delivering it before another worker may retry it.
+const mailDeliveryLease = 5 * time.Minute
+
+// enqueueMail stores an email in the outbox. It is delivered in the
+// background by deliverMail, so slow or failing SMTP never affects the
+// request that triggered it.
+func (app *application) enqueueMail(ctx context.Context, templateFile, username, email string, data any, attachments ...mailer.Attachment) error {
+	mail, err := app.newMail(templateFile, username, email, data, attachments...)
+	if err != nil {
+		return err
+	}
+
+	return app.store.Mail.Enqueue(ctx, mail)
+}
+
+// newMail prepares an email for the outbox, for stores that queue it in the
+// same transaction as the changes it tells about.
+func (app *application) newMail(templateFile, username, email string, data any, attachments ...mailer.Attachment) (*store.Mail, error) {
+	vars, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	mail := &store.Mail{
+		Template:  templateFile,
+		Username:  username,
+		Email:     email,
+		Data:      vars,
+		IsSandbox: app.config.env != "production",
+	}
+
+	for _, a := range attachments {
+		mail.Attachments = append(mail.Attachments, store.MailAttachment(a))
+	}
+
+	return mail, nil
+}
+
+// deliverMail periodically sends the emails waiting in the outbox. Failed
+// deliveries are retried with exponential backoff until the attempts run
+// out, after which the email is dead-lettered. It stops when ctx is
+// cancelled.
+func (app *application) deliverMail(ctx context.Context) {
+	ticker := time.NewTicker(app.config.mail.outbox.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			mails, err := app.store.Mail.ClaimDue(ctx, app.config.mail.outbox.batchSize, mailDeliveryLease)
+			if err != nil {
+				app.logger.Errorw("error claiming outbox emails", "error", err)
+				continue
+			}
+
+			for i := range mails {
+				app.deliver(ctx, &mails[i])
+			}
+		}
+	}
+}
+
+func (app *application) deliver(ctx context.Context, mail *store.Mail) {
+	err := app.sendMail(mail)
+	if err == nil {
+		if err := app.store.Mail.MarkSent(ctx, mail.ID); err != nil {
+			app.logger.Errorw("error marking email as sent", "mail", mail.ID, "error", err)
+		}
+		app.logger.Infow("email sent", "mail", mail.ID, "template", mail.Template, "email", mail.Email)
+		return
+	}
+
+	var retryAt *time.Time
+	if mail.Attempts < app.config.mail.outbox.maxAttempts {
+		next := time.Now().Add(mailBackoff(mail.Attempts, app.config.mail.outbox.backoff, app.config.mail.outbox.maxBackoff))
+		retryAt = &next
+
+		app.logger.Warnw("error sending email, will retry",
+			"mail", mail.ID, "attempt", mail.Attempts, "retry_at", next, "error", err)
+	} else {
+		app.logger.Errorw("error sending email, giving up",
+			"mail", mail.ID, "attempts", mail.Attempts, "email", mail.Email, "error", err)
+	}
+
+	if err := app.store.Mail.MarkFailed(ctx, mail.ID, err.Error(), retryAt); err != nil {
+		app.logger.Errorw("error recording failed email", "mail", mail.ID, "error", err)
+	}
+}
+
+func (app *application) sendMail(mail *store.Mail) error {
+	var data map[string]any
+	if err := json.Unmarshal(mail.Data, &data); err != nil {
+		return err
+	}
+
+	attachments := make([]mailer.Attachment, 0, len(mail.Attachments))
+	for _, a := range mail.Attachments {
+		attachments = append(attachments, mailer.Attachment(a))
+	}
+
+	return app.mailer.Send(mail.Template, mail.Username, mail.Email, data, mail.IsSandbox, attachments...)
+}
+
+// mailBackoff returns the delay before the next delivery attempt: base after
+// the first failure, doubling with every further one, up to max.
+func mailBackoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"os"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -10,6 +12,8 @@ import (
 	"github.com/k5sha/Tikceto/internal/auth"
 	"github.com/k5sha/Tikceto/internal/db"
 	"github.com/k5sha/Tikceto/internal/env"
+	"github.com/k5sha/Tikceto/internal/eticket"
+	"github.com/k5sha/Tikceto/internal/lockout"
 	"github.com/k5sha/Tikceto/internal/mailer"
 	"github.com/k5sha/Tikceto/internal/payment"
 	"github.com/k5sha/Tikceto/internal/s3"
@@ -55,10 +59,40 @@ func main() {
 				password: env.GetString("AUTH_BASIC_PASSWORD", "admin"),
 			},
 			token: tokenConfig{
-				secret: env.GetString("AUTH_TOKEN_SECRET", "secret"),
-				exp:    env.GetDuration("AUTH_TOKEN_EXPIRATION", 3*24*time.Hour),
-				iss:    env.GetString("AUTH_TOKEN_HOST", "blogo"),
+				algorithm:     env.GetString("AUTH_TOKEN_ALGORITHM", auth.AlgEdDSA),
+				secret:        env.GetString("AUTH_TOKEN_SECRET", "secret"),
+				exp:           env.GetDuration("AUTH_TOKEN_EXPIRATION", 15*time.Minute),
+				refreshExp:    env.GetDuration("AUTH_REFRESH_TOKEN_EXPIRATION", 30*24*time.Hour),
+				iss:           env.GetString("AUTH_TOKEN_HOST", "blogo"),
+				keyRotation:   env.GetDuration("AUTH_KEY_ROTATION_INTERVAL", 30*24*time.Hour),
+				keyPrepublish: env.GetDuration("AUTH_KEY_PREPUBLISH", time.Hour),
+				keyRefresh:    env.GetDuration("AUTH_KEY_REFRESH_INTERVAL", time.Minute),
 			},
+			twoFactor: twoFactorConfig{
+				issuer:       env.GetString("TWO_FACTOR_ISSUER", "Tikceto"),
+				challengeExp: env.GetDuration("TWO_FACTOR_CHALLENGE_EXPIRATION", 5*time.Minute),
+			},
+			lockout: lockoutConfig{
+				backend: env.GetString("LOGIN_LOCKOUT_BACKEND", lockout.BackendPostgres),
+				account: lockout.Policy{
+					FreeAttempts:    env.GetInt("LOGIN_FREE_ATTEMPTS", 3),
+					MaxAttempts:     env.GetInt("LOGIN_MAX_ATTEMPTS", 10),
+					BaseDelay:       env.GetDuration("LOGIN_BASE_DELAY", time.Second),
+					LockoutDuration: env.GetDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+					Window:          env.GetDuration("LOGIN_ATTEMPT_WINDOW", time.Hour),
+				},
+				ip: lockout.Policy{
+					FreeAttempts:    env.GetInt("LOGIN_IP_FREE_ATTEMPTS", 20),
+					MaxAttempts:     env.GetInt("LOGIN_IP_MAX_ATTEMPTS", 100),
+					BaseDelay:       env.GetDuration("LOGIN_BASE_DELAY", time.Second),
+					LockoutDuration: env.GetDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+					Window:          env.GetDuration("LOGIN_ATTEMPT_WINDOW", time.Hour),
+				},
+			},
+			passwordResetExp:      env.GetDuration("PASSWORD_RESET_EXPIRATION", 30*time.Minute),
+			accountDeletionExp:    env.GetDuration("ACCOUNT_DELETION_EXPIRATION", time.Hour),
+			activationGrace:       env.GetDuration("USER_ACTIVATION_GRACE_PERIOD", 7*24*time.Hour),
+			inactivePurgeInterval: env.GetDuration("USER_PURGE_INTERVAL", time.Hour),
 		},
 		mail: mailConfig{
 			exp:       env.GetDuration("MAIL_TOKEN_EXPIRATION", 1*time.Hour),
@@ -69,6 +103,13 @@ func main() {
 				host:     env.GetString("SMTP_HOST", "smtp.gmail.com"),
 				port:     env.GetInt("SMTP_PORT", 465),
 			},
+			outbox: outboxConfig{
+				interval:    env.GetDuration("MAIL_OUTBOX_INTERVAL", 5*time.Second),
+				batchSize:   env.GetInt("MAIL_OUTBOX_BATCH_SIZE", 20),
+				maxAttempts: env.GetInt("MAIL_MAX_ATTEMPTS", 8),
+				backoff:     env.GetDuration("MAIL_RETRY_BACKOFF", 30*time.Second),
+				maxBackoff:  env.GetDuration("MAIL_RETRY_MAX_BACKOFF", time.Hour),
+			},
 		},
 		s3: s3Config{
 			bucketName: env.GetString("S3_BUCKET_NAME", "tikceto"),
@@ -81,16 +122,41 @@ func main() {
 			},
 		},
 		payment: payConfig{
-			pubKey:      env.GetString("PAYMENT_PUBLIC_KEY", ""),
-			privateKey:  env.GetString("PAYMENT_PRIVATE_KEY", ""),
-			frontendURL: env.GetString("PAYMENT_FRONTEND_URL", "http://192.168.0.171:5173/payment/complete/"),
-			serverURL:   env.GetString("PAYMENT_SERVER_URL", "http://192.168.0.171/v1/payments/validate"),
+			provider:          env.GetString("PAYMENT_PROVIDER", payment.ProviderLiqPay),
+			checkoutURL:       env.GetString("PAYMENT_CHECKOUT_URL", "http://localhost:8080/v1/payments/fake"),
+			apiURL:            env.GetString("PAYMENT_API_URL", ""),
+			pubKey:            env.GetString("PAYMENT_PUBLIC_KEY", ""),
+			privateKey:        env.GetString("PAYMENT_PRIVATE_KEY", ""),
+			frontendURL:       env.GetString("PAYMENT_FRONTEND_URL", "http://192.168.0.171:5173/payment/complete/"),
+			serverURL:         env.GetString("PAYMENT_SERVER_URL", "http://192.168.0.171/v1/payments/validate"),
+			reconcileInterval: env.GetDuration("PAYMENT_RECONCILE_INTERVAL", 5*time.Minute),
+			reconcileAfter:    env.GetDuration("PAYMENT_RECONCILE_AFTER", 10*time.Minute),
+			reconcileWindow:   env.GetDuration("PAYMENT_RECONCILE_WINDOW", 24*time.Hour),
+		},
+		tickets: ticketsConfig{
+			holdExp:           env.GetDuration("SEAT_HOLD_EXPIRATION", 15*time.Minute),
+			holdSweepInterval: env.GetDuration("SEAT_HOLD_SWEEP_INTERVAL", time.Minute),
+			cancelCutoff:      env.GetDuration("TICKET_CANCEL_CUTOFF", time.Hour),
+			codeSecret:        env.GetString("TICKET_CODE_SECRET", "secret"),
+			checkinOpens:      env.GetDuration("TICKET_CHECKIN_OPENS", time.Hour),
+			checkinCloses:     env.GetDuration("TICKET_CHECKIN_CLOSES", 30*time.Minute),
+		},
+		sessions: sessionsConfig{
+			cleaningBuffer: env.GetDuration("SESSION_CLEANING_BUFFER", 15*time.Minute),
+			timeZone:       env.GetString("SESSION_TIME_ZONE", "Europe/Kyiv"),
 		},
 	}
 
 	// Logger
 	logger := zap.Must(zap.NewProduction()).Sugar()
 
+	// Refunds
+	refundPolicy, err := parseRefundPolicy(env.GetString("TICKET_REFUND_POLICY", "24h=100,1h=50"))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	cfg.tickets.refundPolicy = refundPolicy
+
 	// Migration
 
 	migrationsPath := "/app/cmd/migrate/migrations"
@@ -144,7 +210,47 @@ func main() {
 	}
 
 	// Auth
-	jwtAuthenticator := auth.NewJWTAuthenticator(cfg.auth.token.secret, cfg.auth.token.iss, cfg.auth.token.iss)
+	var authenticator auth.Authenticator
+	switch cfg.auth.token.algorithm {
+	case auth.AlgHS256:
+		if cfg.auth.token.secret == "secret" && cfg.env == "production" {
+			logger.Fatal("AUTH_TOKEN_SECRET must be set when signing tokens with HS256 in production")
+		}
+		authenticator = auth.NewJWTAuthenticator(cfg.auth.token.secret, cfg.auth.token.iss, cfg.auth.token.iss)
+	case auth.AlgRS256, auth.AlgEdDSA:
+		authenticator = auth.NewKeySetAuthenticator(cfg.auth.token.iss, cfg.auth.token.iss)
+	default:
+		logger.Fatalf("unsupported token signing algorithm %q", cfg.auth.token.algorithm)
+	}
+
+	// Login lockout
+	var lockoutBackend lockout.Backend
+	switch cfg.auth.lockout.backend {
+	case lockout.BackendPostgres:
+		lockoutBackend = store.LoginAttempts
+	case lockout.BackendMemory:
+		lockoutBackend = lockout.NewMemoryBackend()
+	default:
+		logger.Fatalf("unsupported login lockout backend %q", cfg.auth.lockout.backend)
+	}
+
+	// Sessions
+	cfg.sessions.location, err = time.LoadLocation(cfg.sessions.timeZone)
+	if err != nil {
+		logger.Fatalf("invalid session time zone %q: %v", cfg.sessions.timeZone, err)
+	}
+
+	// Proxies
+	cfg.trustedProxies, err = parseTrustedProxies(env.GetString("TRUSTED_PROXIES", ""))
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// E-tickets
+	if (cfg.tickets.codeSecret == "" || cfg.tickets.codeSecret == "secret") && cfg.env == "production" {
+		logger.Fatal("TICKET_CODE_SECRET must be set in production")
+	}
+	eticketSigner := eticket.NewSigner(cfg.tickets.codeSecret)
 
 	// S3
 	s3, err := s3.NewMinioClient(cfg.s3.minio.endpoint, cfg.s3.minio.endpointPublic, cfg.s3.minio.user, cfg.s3.minio.password, cfg.s3.bucketName, cfg.s3.minio.ssl)
@@ -153,20 +259,37 @@ func main() {
 	}
 
 	// Payment
-	payment, err := payment.NewLiqPayPaymentService(cfg.payment.pubKey, cfg.payment.privateKey, cfg.payment.frontendURL, cfg.payment.serverURL)
+	if cfg.payment.provider == payment.ProviderFake && cfg.env == "production" {
+		logger.Fatal("the fake payment provider cannot be used in production")
+	}
+
+	payment, err := payment.New(cfg.payment.provider, payment.Config{
+		PublicKey:   cfg.payment.pubKey,
+		PrivateKey:  cfg.payment.privateKey,
+		FrontendURL: cfg.payment.frontendURL,
+		ServerURL:   cfg.payment.serverURL,
+		CheckoutURL: cfg.payment.checkoutURL,
+		APIURL:      cfg.payment.apiURL,
+	})
 	if err != nil {
 		logger.Fatal(err)
 	}
 
 	// Application
 	app := &application{
-		authenticator: jwtAuthenticator,
+		authenticator: authenticator,
+		eticket:       eticketSigner,
 		config:        cfg,
 		store:         store,
 		logger:        logger,
 		mailer:        mailer,
 		s3:            s3,
 		payment:       payment,
+		logins:        newLoginLimiter(lockoutBackend, cfg.auth.lockout),
+	}
+
+	if err := app.syncSigningKeys(context.Background()); err != nil {
+		logger.Fatal(err)
 	}
 
 	// Routing
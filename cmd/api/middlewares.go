@@ -3,10 +3,13 @@ package main
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/k5sha/Tikceto/internal/store"
+	"net"
 	"net/http"
+	"net/netip"
 	"strconv"
 	"strings"
 )
@@ -76,25 +79,59 @@ func (app *application) AuthTokenMiddleware() func(http.Handler) http.Handler {
 				return
 			}
 
+			sessionID, _ := claims["sid"].(string)
+			if sessionID == "" {
+				app.unauthorizedErrorResponse(w, r, fmt.Errorf("token has no session"))
+				return
+			}
+
 			ctx := r.Context()
 
+			revoked, err := app.store.Tokens.IsRevoked(ctx, sessionID)
+			if err != nil {
+				switch {
+				case errors.Is(err, store.ErrNotFound):
+					app.unauthorizedErrorResponse(w, r, err)
+				default:
+					app.internalServerError(w, r, err)
+				}
+				return
+			}
+
+			if revoked {
+				app.unauthorizedErrorResponse(w, r, store.ErrTokenRevoked)
+				return
+			}
+
 			user, err := app.getUser(ctx, userID)
 			if err != nil {
 				app.unauthorizedErrorResponse(w, r, err)
 				return
 			}
 
+			if user.BannedAt != nil {
+				app.accountBannedResponse(w, r)
+				return
+			}
+
 			ctx = context.WithValue(ctx, userCtx, user)
+			ctx = context.WithValue(ctx, authSessionCtx, &authSession{
+				ID:  sessionID,
+				MFA: hasAuthMethod(claims, "otp"),
+			})
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-func (app *application) checkPermissions(role string, next http.HandlerFunc) http.HandlerFunc {
+// requirePermission lets the request through only if the role of the current
+// user grants the permission. Roles that require two-factor authentication
+// must also have signed in with a second factor.
+func (app *application) requirePermission(permission string, next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := getUserFromCtx(r)
 
-		allowed, err := app.checkRolePrecedence(r.Context(), user, role)
+		allowed, err := app.store.Roles.HasPermission(r.Context(), user.Role.ID, permission)
 		if err != nil {
 			app.internalServerError(w, r, err)
 			return
@@ -105,16 +142,112 @@ func (app *application) checkPermissions(role string, next http.HandlerFunc) htt
 			return
 		}
 
+		if user.Role.RequiresMFA && !getAuthSessionFromCtx(r).MFA {
+			app.twoFactorRequiredResponse(w, r)
+			return
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
 
-func (app *application) checkRolePrecedence(ctx context.Context, user *store.User, roleName string) (bool, error) {
-	role, err := app.store.Roles.GetByName(ctx, roleName)
+// hasAuthMethod reports whether the "amr" claim of a token lists method.
+func hasAuthMethod(claims jwt.MapClaims, method string) bool {
+	amr, _ := claims["amr"].([]any)
+	for _, m := range amr {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// realIP replaces the remote address of requests forwarded by a trusted proxy
+// with the address of the client. Forwarding headers of other peers are
+// ignored, as clients could set them to pose as someone else.
+func (app *application) realIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := app.forwardedFor(r); ip != "" {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedFor returns the client address reported by the trusted proxies a
+// request came through, or an empty string if there is none.
+func (app *application) forwardedFor(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		app.logger.Errorw("Role not found", "roleName", roleName)
-		return false, err
+		host = r.RemoteAddr
+	}
+
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !app.trustedProxy(peer) {
+		return ""
+	}
+
+	// Every proxy appends the address it got the request from, so the last
+	// address not belonging to a trusted proxy is the client. Anything left
+	// of it may have been sent by the client itself.
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				return ""
+			}
+			if !app.trustedProxy(addr) {
+				return addr.String()
+			}
+		}
+		return ""
+	}
+
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.String()
+	}
+
+	return ""
+}
+
+func (app *application) trustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range app.config.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses a comma-separated list of addresses and CIDR
+// ranges such as "10.0.0.0/8,192.168.0.171".
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if strings.Contains(part, "/") {
+			prefix, err := netip.ParsePrefix(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", part, err)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", part, err)
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
 	}
 
-	return user.Role.Level >= role.Level, nil
+	return proxies, nil
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/k5sha/Tikceto/internal/store"
+	"net/http"
+)
+
+// GetOrder godoc
+//
+//	@Summary		Fetches an order
+//	@Description	Fetches an order of the current user together with its tickets
+//	@Tags			orders
+//	@Accept			json
+//	@Produce		json
+//	@Param			orderID	path		string	true	"Order ID"
+//	@Success		200		{object}	store.Order
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/orders/{orderID} [get]
+func (app *application) getOrderHandler(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderID")
+	if orderID == "" {
+		app.badRequestResponse(w, r, fmt.Errorf("must provide a id"))
+		return
+	}
+
+	ctx := r.Context()
+
+	order, err := app.store.Orders.GetByID(ctx, orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	user := getUserFromCtx(r)
+	if !order.OwnedBy(user.ID) {
+		app.notFoundResponse(w, r, store.ErrNotFound)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, order); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
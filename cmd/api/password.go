@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/k5sha/Tikceto/internal/mailer"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+// ForgotPasswordPayload represents the payload for requesting a password reset.
+//
+//	@Email	string "The email address of the account" validate:"required,email,max=255"
+type ForgotPasswordPayload struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// forgotPasswordHandler godoc
+//
+//	@Summary		Requests a password reset
+//	@Description	Emails a password reset link if an active account with the given email exists.
+//	@Description	The response is the same whether or not the account exists.
+//	@Tags			authentication
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ForgotPasswordPayload	true	"Account email"
+//	@Success		202		{string}	string					"Reset link sent if the account exists"
+//	@Failure		400		{object}	error
+//	@Router			/authentication/password/forgot [post]
+func (app *application) forgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ForgotPasswordPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(&payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Failures are only logged so that the response never tells whether an
+	// account with this email exists.
+	if err := app.sendPasswordReset(r, payload.Email); err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.logger.Errorw("error sending password reset", "error", err)
+	}
+
+	if err := app.jsonResponse(w, http.StatusAccepted, "if the account exists, a password reset link has been sent"); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+func (app *application) sendPasswordReset(r *http.Request, email string) error {
+	ctx := r.Context()
+
+	user, err := app.store.Users.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsActive {
+		return store.ErrNotFound
+	}
+
+	plainToken := uuid.New().String()
+
+	hash := sha256.Sum256([]byte(plainToken))
+	hashToken := hex.EncodeToString(hash[:])
+
+	if err := app.store.Users.CreatePasswordReset(ctx, user.ID, hashToken, app.config.auth.passwordResetExp); err != nil {
+		return err
+	}
+
+	vars := struct {
+		Username string
+		ResetURL string
+	}{
+		Username: user.Username,
+		ResetURL: fmt.Sprintf("%s/password/reset/%s", app.config.frontendURL, plainToken),
+	}
+
+	return app.enqueueMail(ctx, mailer.PasswordResetTemplate, user.Username, user.Email, vars)
+}
+
+// ResetPasswordPayload represents the payload for setting a new password.
+//
+//	@Password	string "The new password" validate:"required,min=8,max=72"
+type ResetPasswordPayload struct {
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// resetPasswordHandler godoc
+//
+//	@Summary		Resets a password
+//	@Description	Sets a new password using a reset token and invalidates every outstanding reset token of the user
+//	@Tags			authentication
+//	@Accept			json
+//	@Produce		json
+//	@Param			token	path		string					true	"Password reset token"
+//	@Param			payload	body		ResetPasswordPayload	true	"New password"
+//	@Success		204		{string}	string					"Password changed"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/authentication/password/reset/{token} [put]
+func (app *application) resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	var payload ResetPasswordPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(&payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := &store.User{}
+	if err := user.Password.Set(payload.Password); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.store.Users.ResetPassword(r.Context(), token, user); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
@@ -1,41 +1,55 @@
 package main
 
 import (
-	"encoding/base64"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
+	"github.com/go-chi/chi/v5"
 	"github.com/k5sha/Tikceto/internal/payment"
 	"github.com/k5sha/Tikceto/internal/store"
-	"log"
+	"math"
 	"net/http"
+	"strconv"
 )
 
-// CreatePaymentPayload represents the payload for creating a seat.
+// CreatePaymentPayload represents the payload for creating an order payment.
 //
-//	@SessionID	int  "SessionID" validate:"required"
-//	@SeatID		int  "SessionID" validate:"required"
+//	@Items	[]OrderItemPayload	"Seats to buy in a single order" validate:"required,min=1,max=10,dive"
 type CreatePaymentPayload struct {
-	SessionID int64 `json:"session_id" validate:"required"`
-	SeatID    int64 `json:"seat_id" validate:"required"`
+	Items []OrderItemPayload `json:"items" validate:"required,min=1,max=10,dive"`
+}
+
+// OrderItemPayload represents a single seat in an order.
+//
+//	@SessionID	int64	"Session ID" validate:"required,gte=1"
+//	@SeatID		int64	"Seat ID" validate:"required,gte=1"
+type OrderItemPayload struct {
+	SessionID int64 `json:"session_id" validate:"required,gte=1"`
+	SeatID    int64 `json:"seat_id" validate:"required,gte=1"`
 }
 
 // CreatePaymentHandler godoc
 //
 //	@Summary		Create a payment link
-//	@Description	Creates a payment link for a ticket through LiqPay
+//	@Description	Creates an order for one or more seats and a single payment link for its total through the configured payment provider
 //	@Tags			payments
 //	@Accept			json
 //	@Produce		json
 //	@Param			payload	body		CreatePaymentPayload	true	"Payment request payload"
 //	@Success		201		{object}	payment.PaymentResponse
 //	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/payments/create [post]
 func (app *application) createPaymentHandler(w http.ResponseWriter, r *http.Request) {
 	var payload CreatePaymentPayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
 		app.badRequestResponse(w, r, err)
 		return
 	}
@@ -44,54 +58,123 @@ func (app *application) createPaymentHandler(w http.ResponseWriter, r *http.Requ
 
 	user := getUserFromCtx(r)
 
-	session, err := app.store.Sessions.GetByID(ctx, payload.SessionID)
-	if err != nil {
-		switch {
-		case errors.Is(err, store.ErrNotFound):
-			app.notFoundResponse(w, r, err)
-		default:
-			app.internalServerError(w, r, err)
+	order := &store.Order{
+		UserID:   &user.ID,
+		Currency: "UAH",
+	}
+
+	sessions := make(map[int64]*store.Session)
+	seen := make(map[OrderItemPayload]bool)
+
+	for _, item := range payload.Items {
+		if seen[item] {
+			app.badRequestResponse(w, r, fmt.Errorf("seat %d is listed twice for session %d", item.SeatID, item.SessionID))
+			return
 		}
-		return
+		seen[item] = true
+
+		session, ok := sessions[item.SessionID]
+		if !ok {
+			var err error
+			session, err = app.store.Sessions.GetByID(ctx, item.SessionID)
+			if err != nil {
+				switch {
+				case errors.Is(err, store.ErrNotFound):
+					app.notFoundResponse(w, r, err)
+				default:
+					app.internalServerError(w, r, err)
+				}
+				return
+			}
+
+			if session.Status == store.SessionStatusCancelled {
+				app.badRequestResponse(w, r, store.ErrSessionCancelled)
+				return
+			}
+			sessions[item.SessionID] = session
+		}
+
+		seat, err := app.store.Seats.GetByID(ctx, item.SeatID)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		if session.RoomID != seat.RoomID {
+			app.badRequestResponse(w, r, errors.New("session and seat belong to different rooms"))
+			return
+		}
+
+		price := session.PriceFor(seat.Type)
+
+		order.Tickets = append(order.Tickets, store.Ticket{
+			SessionID: session.ID,
+			SeatID:    seat.ID,
+			Price:     price,
+		})
+		order.Amount += price
 	}
 
-	seat, err := app.store.Seats.GetByID(ctx, payload.SeatID)
+	order.Amount = math.Round(order.Amount*100) / 100
+
+	err := app.store.Orders.Create(ctx, order, app.config.tickets.holdExp)
 	if err != nil {
 		switch {
 		case errors.Is(err, store.ErrNotFound):
 			app.notFoundResponse(w, r, err)
+		case errors.Is(err, store.ErrDuplicateTicket), errors.Is(err, store.ErrSessionCancelled):
+			app.badRequestResponse(w, r, err)
 		default:
 			app.internalServerError(w, r, err)
 		}
 		return
 	}
 
-	ticket := &store.Ticket{
-		UserID:    user.ID,
-		SessionID: session.ID,
-		SeatID:    seat.ID,
-		Price:     session.Price,
+	attempt := &store.Payment{
+		OrderID:         order.ID,
+		Provider:        app.payment.Name(),
+		ProviderOrderID: order.ID,
+		Amount:          order.Amount,
+		Currency:        order.Currency,
 	}
 
-	err = app.store.Tickets.Create(ctx, ticket)
-	if err != nil {
-		if errors.Is(err, store.ErrNotFound) {
-			app.notFoundResponse(w, r, err)
-			return
-		}
+	if err := app.store.Payments.Create(ctx, attempt); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
 	paymentPayload := payment.PaymentRequest{
-		Amount:      ticket.Price,
-		Currency:    "UAH",
-		Description: "Купівля квитка #" + ticket.ID,
-		OrderId:     ticket.ID,
+		Amount:      order.Amount,
+		Currency:    order.Currency,
+		Description: fmt.Sprintf("Купівля квитків (%d шт.), замовлення #%s", len(order.Tickets), order.ID),
+		OrderId:     order.ID,
 	}
 
 	paymentResp, err := app.payment.CreatePayment(paymentPayload)
 	if err != nil {
+		failed := store.PaymentStatusFailed
+		event := &store.PaymentEvent{
+			Provider:        attempt.Provider,
+			ProviderOrderID: &attempt.ProviderOrderID,
+			SignatureValid:  true,
+			ToStatus:        &failed,
+			RawPayload:      err.Error(),
+		}
+		if err := app.store.Payments.Settle(ctx, attempt, event); err != nil {
+			app.logger.Errorw("error releasing order after failed payment request", "order", order.ID, "error", err)
+		}
+
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	attempt.CheckoutURL = &paymentResp.Url
+	if err := app.store.Payments.Update(ctx, attempt); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
@@ -105,72 +188,245 @@ func (app *application) createPaymentHandler(w http.ResponseWriter, r *http.Requ
 // ValidatePaymentHandler godoc
 //
 //	@Summary		Validate payment status
-//	@Description	Validates the payment status by order ID
+//	@Description	Records the provider callback in the payments ledger and confirms or releases every ticket of the order.
+//	@Description	Callbacks are deduplicated by provider transaction ID; repeated or out-of-order callbacks are acknowledged without changes.
 //	@Tags			payments
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{object}	map[string]string
 //	@Failure		400	{object}	error
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
 //	@Failure		500	{object}	error
 //	@Router			/payments/validate [post]
 func (app *application) validatePaymentHandler(w http.ResponseWriter, r *http.Request) {
-	data := r.FormValue("data")
-	signature := r.FormValue("signature")
+	ctx := r.Context()
 
-	expectedSignature := app.payment.GenerateSignature(data)
+	callback, err := app.payment.ParseCallback(r)
+	if err != nil {
+		event := &store.PaymentEvent{
+			Provider:       app.payment.Name(),
+			SignatureValid: callback.SignatureValid,
+			RawPayload:     callback.Payload,
+		}
+		if err := app.store.Payments.RecordEvent(ctx, event); err != nil {
+			app.logger.Errorw("error recording payment event", "error", err)
+		}
 
-	if signature != expectedSignature {
-		app.unauthorizedErrorResponse(w, r, fmt.Errorf("invalid signature"))
+		switch {
+		case errors.Is(err, payment.ErrInvalidSignature):
+			app.unauthorizedErrorResponse(w, r, err)
+		default:
+			app.badRequestResponse(w, r, err)
+		}
 		return
 	}
 
-	decodedData, err := base64.StdEncoding.DecodeString(data)
+	event := newPaymentEvent(app.payment.Name(), callback)
+
+	attempt, err := app.store.Payments.GetByProviderOrderID(ctx, event.Provider, callback.OrderID)
 	if err != nil {
-		app.internalServerError(w, r, err)
+		if err := app.store.Payments.RecordEvent(ctx, event); err != nil {
+			app.logger.Errorw("error recording payment event", "error", err)
+		}
+
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
 		return
 	}
 
-	var paymentData PaymentData
-
-	err = json.Unmarshal(decodedData, &paymentData)
+	outcome, err := app.settlePayment(ctx, attempt, callback, event)
 	if err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
-	log.Println(paymentData.Status)
+	app.paymentCallbackResponse(w, r, outcome)
+}
 
-	ctx := r.Context()
+// settlePayment applies a verified provider report about a payment attempt,
+// whether it arrived as a callback or was fetched by the reconciler, and
+// returns how it was handled.
+func (app *application) settlePayment(ctx context.Context, attempt *store.Payment, callback *payment.Callback, event *store.PaymentEvent) (string, error) {
+	status := callback.Status
 
-	ticket, err := app.store.Tickets.GetByID(ctx, paymentData.OrderID)
-	if err != nil {
-		app.notFoundResponse(w, r, err)
-		return
+	if status != attempt.Status && !store.CanTransitionPayment(attempt.Status, status) {
+		app.logger.Warnw("ignoring payment report with illegal transition",
+			"payment", attempt.ID, "from", attempt.Status, "to", status, "provider_status", callback.ProviderStatus)
+
+		if err := app.store.Payments.RecordEvent(ctx, event); err != nil && !errors.Is(err, store.ErrDuplicatePaymentEvent) {
+			return "", err
+		}
+
+		return "ignored", nil
 	}
 
-	if paymentData.Status == "success" || paymentData.Status == "sandbox" {
-		ticket.Status = "confirmed"
-		if err := app.store.Tickets.Update(ctx, ticket); err != nil {
-			http.Error(w, "Failed to update ticket status", http.StatusInternalServerError)
-			return
+	event.ToStatus = &status
+	from := attempt.Status
+
+	err := app.store.Payments.Settle(ctx, attempt, event)
+	switch {
+	case err == nil:
+		if from != store.PaymentStatusConfirmed && attempt.Status == store.PaymentStatusConfirmed {
+			go app.sendOrderConfirmation(attempt.OrderID)
 		}
-	} else {
-		ticket.Status = "failed"
-		if err := app.store.Tickets.Delete(ctx, ticket.ID); err != nil {
-			http.Error(w, "Failed to delete ticket", http.StatusInternalServerError)
-			return
+		return "ok", nil
+	case errors.Is(err, store.ErrDuplicatePaymentEvent):
+		return "already processed", nil
+	case errors.Is(err, store.ErrInvalidTransition):
+		app.logger.Warnw("payment changed concurrently, ignoring report", "payment", attempt.ID, "to", status)
+		return "ignored", nil
+	case errors.Is(err, store.ErrPartiallyRefunded):
+		app.logger.Infow("payment refunded ticket by ticket, ignoring report", "payment", attempt.ID, "to", status)
+		return "ignored", nil
+	case errors.Is(err, store.ErrOrderNotPending):
+		app.logger.Warnw("payment confirmed for a released order, refunding", "payment", attempt.ID, "order", attempt.OrderID)
+
+		if _, err := app.refund(ctx, attempt, nil, attempt.Amount, "payment confirmed after the order was released"); err != nil {
+			app.logger.Errorw("error refunding payment for a released order", "payment", attempt.ID, "error", err)
 		}
+		return "refunded", nil
+	default:
+		return "", err
 	}
+}
 
+// newPaymentEvent prepares the ledger entry for a provider report.
+func newPaymentEvent(provider string, callback *payment.Callback) *store.PaymentEvent {
+	event := &store.PaymentEvent{
+		Provider:        provider,
+		ProviderOrderID: &callback.OrderID,
+		ProviderStatus:  &callback.ProviderStatus,
+		Amount:          &callback.Amount,
+		Currency:        &callback.Currency,
+		SignatureValid:  callback.SignatureValid,
+		RawPayload:      callback.Payload,
+	}
+
+	if callback.TransactionID != "" {
+		event.ProviderTransactionID = &callback.TransactionID
+	}
+
+	return event
+}
+
+// paymentCallbackResponse acknowledges a provider callback. Anything but a 2xx
+// makes the provider retry, so callbacks that are valid but need no further
+// processing are acknowledged too.
+func (app *application) paymentCallbackResponse(w http.ResponseWriter, r *http.Request, status string) {
 	if err := app.jsonResponse(w, http.StatusOK, map[string]string{
-		"status": "ok",
+		"status": status,
 	}); err != nil {
 		app.internalServerError(w, r, err)
+	}
+}
+
+// GetPayments godoc
+//
+//	@Summary		Fetches the payments ledger
+//	@Description	Fetches payment attempts with optional filters for reconciliation
+//	@Tags			payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			since	query		string	false	"Since date (YYYY-MM-DD)"
+//	@Param			until	query		string	false	"Until date (YYYY-MM-DD)"
+//	@Param			status	query		string	false	"Payment status"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Param			sort	query		string	false	"Sort order (asc|desc)"
+//	@Success		200		{object}	store.PaginatedPaymentsResponse
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/payments [get]
+func (app *application) getPaymentsHandler(w http.ResponseWriter, r *http.Request) {
+	pq := store.PaginatedPaymentsQuery{
+		Limit:  50,
+		Offset: 0,
+		Sort:   "desc",
+	}
+
+	pq, err := pq.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(pq); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payments, total, err := app.store.Payments.GetList(r.Context(), pq)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	response := store.PaginatedPaymentsResponse{
+		Data:  payments,
+		Total: total,
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
 		return
 	}
 }
 
-type PaymentData struct {
-	OrderID string `json:"order_id"`
-	Status  string `json:"status"`
+// PaymentWithEvents is a payment attempt together with every provider message
+// received for it.
+type PaymentWithEvents struct {
+	store.Payment
+	Events []store.PaymentEvent `json:"events"`
+}
+
+// GetPayment godoc
+//
+//	@Summary		Fetches a payment
+//	@Description	Fetches a payment attempt with its provider callbacks and status transitions
+//	@Tags			payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			paymentID	path		int	true	"Payment ID"
+//	@Success		200			{object}	PaymentWithEvents
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/payments/{paymentID} [get]
+func (app *application) getPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "paymentID"), 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("must provide a correct id"))
+		return
+	}
+
+	ctx := r.Context()
+
+	attempt, err := app.store.Payments.GetByID(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	events, err := app.store.Payments.GetEvents(ctx, attempt.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, PaymentWithEvents{Payment: *attempt, Events: events}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
 }
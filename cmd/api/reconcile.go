@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// reconcileReport summarizes a single reconciliation run.
+type reconcileReport struct {
+	StartedAt  time.Time           `json:"started_at"`
+	FinishedAt time.Time           `json:"finished_at"`
+	Checked    int                 `json:"checked"`
+	Mismatches []reconcileMismatch `json:"mismatches"`
+}
+
+// reconcileMismatch is a payment whose status at the provider differed from
+// the ledger, or that could not be checked.
+type reconcileMismatch struct {
+	PaymentID      int64  `json:"payment_id"`
+	OrderID        string `json:"order_id"`
+	LocalStatus    string `json:"local_status"`
+	ProviderStatus string `json:"provider_status,omitempty"`
+	Outcome        string `json:"outcome,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// reconcilePayments periodically asks the payment provider about payments
+// that stayed pending for longer than expected, in case their callbacks were
+// lost. It stops when ctx is cancelled.
+func (app *application) reconcilePayments(ctx context.Context) {
+	ticker := time.NewTicker(app.config.payment.reconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := app.reconcile(ctx)
+			if err != nil {
+				app.logger.Errorw("error reconciling payments", "error", err)
+				continue
+			}
+
+			app.reconcileReport.Store(report)
+
+			if len(report.Mismatches) > 0 {
+				app.logger.Warnw("reconciled payments", "checked", report.Checked, "mismatches", len(report.Mismatches))
+			}
+		}
+	}
+}
+
+// reconcile checks every pending payment created between the reconciliation
+// window and the reconciliation delay ago, and applies the provider status
+// the same way a callback would.
+func (app *application) reconcile(ctx context.Context) (*reconcileReport, error) {
+	report := &reconcileReport{
+		StartedAt:  time.Now(),
+		Mismatches: []reconcileMismatch{},
+	}
+
+	payments, err := app.store.Payments.GetPending(
+		ctx,
+		app.payment.Name(),
+		report.StartedAt.Add(-app.config.payment.reconcileWindow),
+		report.StartedAt.Add(-app.config.payment.reconcileAfter),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range payments {
+		attempt := &payments[i]
+		report.Checked++
+
+		mismatch := reconcileMismatch{
+			PaymentID:   attempt.ID,
+			OrderID:     attempt.OrderID,
+			LocalStatus: attempt.Status,
+		}
+
+		callback, err := app.payment.Status(attempt.ProviderOrderID)
+		if err != nil {
+			app.logger.Warnw("error fetching payment status", "payment", attempt.ID, "error", err)
+
+			mismatch.Error = err.Error()
+			report.Mismatches = append(report.Mismatches, mismatch)
+			continue
+		}
+
+		if callback.Status == attempt.Status {
+			continue
+		}
+
+		mismatch.ProviderStatus = callback.ProviderStatus
+
+		mismatch.Outcome, err = app.settlePayment(ctx, attempt, callback, newPaymentEvent(attempt.Provider, callback))
+		if err != nil {
+			mismatch.Error = err.Error()
+		}
+
+		app.logger.Infow("payment status mismatch",
+			"payment", attempt.ID, "order", attempt.OrderID, "local_status", mismatch.LocalStatus,
+			"provider_status", mismatch.ProviderStatus, "outcome", mismatch.Outcome, "error", mismatch.Error)
+
+		report.Mismatches = append(report.Mismatches, mismatch)
+	}
+
+	report.FinishedAt = time.Now()
+
+	return report, nil
+}
+
+// GetReconciliationReport godoc
+//
+//	@Summary		Fetches the last payment reconciliation report
+//	@Description	Fetches the payments whose provider status differed from the ledger during the last reconciliation run
+//	@Tags			payments
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	reconcileReport
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/payments/reconciliation [get]
+func (app *application) getReconciliationReportHandler(w http.ResponseWriter, r *http.Request) {
+	report := app.reconcileReport.Load()
+	if report == nil {
+		app.notFoundResponse(w, r, errors.New("no reconciliation has run yet"))
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, report); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
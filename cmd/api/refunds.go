@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k5sha/Tikceto/internal/payment"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+type refundRule struct {
+	before  time.Duration
+	percent float64
+}
+
+// refundPolicy lists the share of the ticket price returned on cancellation,
+// from the earliest cancellation to the latest one.
+type refundPolicy []refundRule
+
+// parseRefundPolicy parses rules such as "24h=100,1h=50": cancelling at least
+// 24 hours before the session returns the full price, at least an hour before
+// returns half of it, and later cancellations return nothing.
+func parseRefundPolicy(s string) (refundPolicy, error) {
+	var policy refundPolicy
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		before, percent, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid refund rule %q", part)
+		}
+
+		d, err := time.ParseDuration(strings.TrimSpace(before))
+		if err != nil {
+			return nil, fmt.Errorf("invalid refund rule %q: %w", part, err)
+		}
+
+		p, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid refund rule %q: percent must be between 0 and 100", part)
+		}
+
+		policy = append(policy, refundRule{before: d, percent: p})
+	}
+
+	sort.Slice(policy, func(i, j int) bool {
+		return policy[i].before > policy[j].before
+	})
+
+	return policy, nil
+}
+
+// percent returns the share of the price refunded when cancelling with left
+// time remaining before the session.
+func (p refundPolicy) percent(left time.Duration) float64 {
+	for _, rule := range p {
+		if left >= rule.before {
+			return rule.percent
+		}
+	}
+	return 0
+}
+
+// CancelTicket godoc
+//
+//	@Summary		Cancels a ticket
+//	@Description	Cancels a confirmed ticket of the current user and refunds it according to the refund policy
+//	@Tags			tickets
+//	@Accept			json
+//	@Produce		json
+//	@Param			ticketID	path		string	true	"Ticket ID"
+//	@Success		200			{object}	store.Refund
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/tickets/{ticketID}/cancel [post]
+func (app *application) cancelTicketHandler(w http.ResponseWriter, r *http.Request) {
+	ticket := getTicketFromCtx(r)
+	user := getUserFromCtx(r)
+
+	if !ticket.OwnedBy(user.ID) {
+		app.notFoundResponse(w, r, store.ErrNotFound)
+		return
+	}
+
+	if ticket.Status != store.TicketStatusConfirmed {
+		app.badRequestResponse(w, r, fmt.Errorf("only confirmed tickets can be cancelled"))
+		return
+	}
+
+	if ticket.OrderID == nil {
+		app.badRequestResponse(w, r, fmt.Errorf("the ticket was not paid online and cannot be refunded"))
+		return
+	}
+
+	startTime, err := time.Parse(time.RFC3339, ticket.Session.StartTime)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	left := time.Until(startTime)
+	if left < app.config.tickets.cancelCutoff {
+		app.badRequestResponse(w, r, fmt.Errorf("tickets can be cancelled no later than %s before the session", app.config.tickets.cancelCutoff))
+		return
+	}
+
+	ctx := r.Context()
+
+	attempt, err := app.store.Payments.GetByOrderID(ctx, *ticket.OrderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if attempt.Status != store.PaymentStatusConfirmed {
+		app.badRequestResponse(w, r, fmt.Errorf("the payment for this ticket is %s", attempt.Status))
+		return
+	}
+
+	amount := math.Round(ticket.Price*app.config.tickets.refundPolicy.percent(left)) / 100
+
+	refund, err := app.refund(ctx, attempt, &ticket.ID, amount, "cancelled by customer")
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrDuplicateRefund), errors.Is(err, store.ErrInvalidTransition):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, refund); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// refund returns amount of the payment to the buyer through the payment
+// provider. With a ticket it refunds that ticket only, without one it refunds
+// the whole payment.
+func (app *application) refund(ctx context.Context, attempt *store.Payment, ticketID *string, amount float64, reason string) (*store.Refund, error) {
+	refund := &store.Refund{
+		PaymentID: attempt.ID,
+		TicketID:  ticketID,
+		Amount:    amount,
+		Reason:    reason,
+	}
+
+	if err := app.store.Refunds.Create(ctx, refund); err != nil {
+		return nil, err
+	}
+
+	// Once the provider is asked for the money the outcome must be stored even
+	// if the client goes away.
+	ctx = context.WithoutCancel(ctx)
+
+	if amount > 0 {
+		resp, err := app.payment.Refund(payment.RefundRequest{
+			OrderId: attempt.ProviderOrderID,
+			Amount:  amount,
+		})
+		if err != nil {
+			providerErr := err.Error()
+			refund.ProviderResponse = &providerErr
+
+			if err := app.store.Refunds.Fail(ctx, refund); err != nil {
+				app.logger.Errorw("error marking refund as failed", "refund", refund.ID, "error", err)
+			}
+			return nil, err
+		}
+
+		refund.ProviderResponse = &resp.Status
+	}
+
+	if err := app.store.Refunds.Complete(ctx, refund); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+type roleKey string
+
+const roleCtx roleKey = "role"
+
+// The admin role always holds every permission, as the database grants it each
+// permission as soon as it is added, and the user role is given to every new
+// account, so neither may be removed.
+const (
+	adminRole = "admin"
+	userRole  = "user"
+)
+
+// CreateRolePayload represents the payload for creating a role.
+//
+//	@Name			string											"Name of the role"		validate:"required,min=3,max=100"
+//	@Description	string											"What the role is for"	validate:"max=255"
+//	@RequiresMFA	bool											"Whether members must sign in with two-factor authentication"
+//	@Permissions	[]string	"Permissions granted by the role"	validate:"unique,dive,max=100"
+type CreateRolePayload struct {
+	Name        string   `json:"name" validate:"required,min=3,max=100"`
+	Description string   `json:"description" validate:"max=255"`
+	RequiresMFA bool     `json:"requires_mfa"`
+	Permissions []string `json:"permissions" validate:"unique,dive,max=100"`
+}
+
+// UpdateRolePayload represents the payload for updating a role.
+//
+//	@Description	string	"What the role is for"	validate:"omitempty,max=255"
+//	@RequiresMFA	bool	"Whether members must sign in with two-factor authentication"
+type UpdateRolePayload struct {
+	Description *string `json:"description" validate:"omitempty,max=255"`
+	RequiresMFA *bool   `json:"requires_mfa"`
+}
+
+// SetRolePermissionsPayload represents the payload for replacing the
+// permissions of a role.
+//
+//	@Permissions	[]string	"Permissions granted by the role"	validate:"unique,dive,max=100"
+type SetRolePermissionsPayload struct {
+	Permissions []string `json:"permissions" validate:"unique,dive,max=100"`
+}
+
+// GetPermissions godoc
+//
+//	@Summary		Fetches permissions
+//	@Description	Lists every permission that can be granted to a role
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{array}		store.Permission
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/permissions [get]
+func (app *application) getPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	permissions, err := app.store.Roles.GetPermissions(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, permissions); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetRoles godoc
+//
+//	@Summary		Fetches roles
+//	@Description	Lists every role with its permissions
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{array}		store.Role
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/roles [get]
+func (app *application) getRolesHandler(w http.ResponseWriter, r *http.Request) {
+	roles, err := app.store.Roles.GetAll(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, roles); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetRole godoc
+//
+//	@Summary		Fetches a role
+//	@Description	Fetches a role with its permissions by ID
+//	@Tags			admin
+//	@Produce		json
+//	@Param			roleID	path		int	true	"Role ID"
+//	@Success		200		{object}	store.Role
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/roles/{roleID} [get]
+func (app *application) getRoleHandler(w http.ResponseWriter, r *http.Request) {
+	role := getRoleFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, role); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// CreateRole godoc
+//
+//	@Summary		Creates a role
+//	@Description	Creates a role with the given permissions
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateRolePayload	true	"Role payload"
+//	@Success		201		{object}	store.Role
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/roles [post]
+func (app *application) createRoleHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateRolePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	role := &store.Role{
+		Name:        payload.Name,
+		Description: payload.Description,
+		RequiresMFA: payload.RequiresMFA,
+		Permissions: payload.Permissions,
+	}
+
+	if err := app.store.Roles.Create(r.Context(), role); err != nil {
+		switch {
+		case errors.Is(err, store.ErrDuplicateRole), errors.Is(err, store.ErrUnknownPermission):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, role); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// UpdateRole godoc
+//
+//	@Summary		Updates a role
+//	@Description	Updates the description and the two-factor requirement of a role
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			roleID	path		int					true	"Role ID"
+//	@Param			payload	body		UpdateRolePayload	true	"Role payload"
+//	@Success		200		{object}	store.Role
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/roles/{roleID} [patch]
+func (app *application) updateRoleHandler(w http.ResponseWriter, r *http.Request) {
+	role := getRoleFromCtx(r)
+
+	var payload UpdateRolePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.Description != nil {
+		role.Description = *payload.Description
+	}
+	if payload.RequiresMFA != nil {
+		if role.Name == adminRole && !*payload.RequiresMFA {
+			app.badRequestResponse(w, r, fmt.Errorf("the %s role always requires two-factor authentication", adminRole))
+			return
+		}
+		role.RequiresMFA = *payload.RequiresMFA
+	}
+
+	if err := app.store.Roles.Update(r.Context(), role); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, role); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// SetRolePermissions godoc
+//
+//	@Summary		Sets the permissions of a role
+//	@Description	Replaces the permissions of a role. The permissions of the admin role cannot be changed.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			roleID	path		int							true	"Role ID"
+//	@Param			payload	body		SetRolePermissionsPayload	true	"Permissions"
+//	@Success		200		{object}	store.Role
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/roles/{roleID}/permissions [put]
+func (app *application) setRolePermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	role := getRoleFromCtx(r)
+
+	if role.Name == adminRole {
+		app.badRequestResponse(w, r, fmt.Errorf("the permissions of the %s role cannot be changed", adminRole))
+		return
+	}
+
+	var payload SetRolePermissionsPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	if err := app.store.Roles.SetPermissions(ctx, role.ID, payload.Permissions); err != nil {
+		switch {
+		case errors.Is(err, store.ErrUnknownPermission):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	role, err := app.store.Roles.GetByID(ctx, role.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, role); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// DeleteRole godoc
+//
+//	@Summary		Deletes a role
+//	@Description	Deletes a role that is not assigned to any user
+//	@Tags			admin
+//	@Produce		json
+//	@Param			roleID	path		int	true	"Role ID"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/roles/{roleID} [delete]
+func (app *application) deleteRoleHandler(w http.ResponseWriter, r *http.Request) {
+	role := getRoleFromCtx(r)
+
+	if role.Name == adminRole || role.Name == userRole {
+		app.badRequestResponse(w, r, fmt.Errorf("the %s role cannot be deleted", role.Name))
+		return
+	}
+
+	if err := app.store.Roles.Delete(r.Context(), role.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		case errors.Is(err, store.ErrRoleInUse):
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := writeJSON(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+func (app *application) rolesContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idParam := chi.URLParam(r, "roleID")
+		id, err := strconv.ParseInt(idParam, 10, 64)
+		if err != nil {
+			app.badRequestResponse(w, r, fmt.Errorf("must provide a correct id"))
+			return
+		}
+		ctx := r.Context()
+
+		role, err := app.store.Roles.GetByID(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, roleCtx, role)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getRoleFromCtx(r *http.Request) *store.Role {
+	role, _ := r.Context().Value(roleCtx).(*store.Role)
+	return role
+}
@@ -247,6 +247,7 @@ func (app *application) fillSeatsInRoom(ctx context.Context, room *store.Room, r
 				RoomID: room.ID,
 				Row:    row,
 				Number: seatNumber,
+				Type:   store.SeatTypeStandard,
 			}
 
 			if err := app.store.Seats.Create(ctx, seat); err != nil {
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+// maxScheduleDays and maxScheduleSessions bound how much a single schedule
+// request may generate.
+const (
+	maxScheduleDays     = 62
+	maxScheduleSessions = 500
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// PriceRule sets the price of the sessions it matches. A rule without days
+// matches every day; a rule without a time range matches every showtime.
+//
+//	@Days	[]string	"Days of the week (mon|tue|wed|thu|fri|sat|sun)"
+//	@From	string			"Earliest showtime the rule applies to (HH:MM)"
+//	@Until	string			"Showtime the rule stops applying at (HH:MM)"
+//	@Price	float64			"Price of the matching sessions"	validate:"gte=0"
+type PriceRule struct {
+	Days  []string `json:"days" validate:"unique,dive,oneof=mon tue wed thu fri sat sun"`
+	From  string   `json:"from" validate:"omitempty,datetime=15:04"`
+	Until string   `json:"until" validate:"omitempty,datetime=15:04"`
+	Price float64  `json:"price" validate:"gte=0"`
+}
+
+// CreateSchedulePayload represents the payload for generating sessions.
+//
+//	@MovieID	int64						"Movie ID"							validate:"required,gte=1"
+//	@RoomIDs	[]int64						"Room IDs"							validate:"required,min=1"
+//	@From		string						"First day (YYYY-MM-DD)"			validate:"required"
+//	@To			string						"Last day, inclusive (YYYY-MM-DD)"	validate:"required"
+//	@Days		[]string				"Days of the week to schedule on, every day if empty"
+//	@Showtimes	[]string				"Local start times (HH:MM)"	validate:"required,min=1"
+//	@Format		string												"Screening format, 2D if empty"
+//	@Price		float64												"Price of sessions no rule matches"	validate:"gte=0"
+//	@Rules		[]PriceRule				"Price rules, the first matching one wins"
+//	@SeatPrices	[]SessionPricePayload	"Prices by seat type, the same at every session"
+//	@DryRun		bool						"Only preview the sessions"
+type CreateSchedulePayload struct {
+	MovieID    int64                 `json:"movie_id" validate:"required,gte=1"`
+	RoomIDs    []int64               `json:"room_ids" validate:"required,min=1,max=20,unique,dive,gte=1"`
+	From       string                `json:"from" validate:"required,datetime=2006-01-02"`
+	To         string                `json:"to" validate:"required,datetime=2006-01-02"`
+	Days       []string              `json:"days" validate:"unique,dive,oneof=mon tue wed thu fri sat sun"`
+	Showtimes  []string              `json:"showtimes" validate:"required,min=1,max=24,unique,dive,datetime=15:04"`
+	Format     string                `json:"format" validate:"omitempty,oneof=2D 3D IMAX 4DX"`
+	Price      float64               `json:"price" validate:"gte=0"`
+	Rules      []PriceRule           `json:"rules" validate:"max=20,dive"`
+	SeatPrices []SessionPricePayload `json:"seat_prices" validate:"unique=SeatType,dive"`
+	DryRun     bool                  `json:"dry_run"`
+}
+
+// CloneSchedulePayload represents the payload for copying a week of sessions.
+//
+//	@RoomID		int64	"Room ID"										validate:"required,gte=1"
+//	@WeekStart	string	"First day of the week to fill (YYYY-MM-DD)"	validate:"required"
+//	@DryRun		bool	"Only preview the sessions"
+type CloneSchedulePayload struct {
+	RoomID    int64  `json:"room_id" validate:"required,gte=1"`
+	WeekStart string `json:"week_start" validate:"required,datetime=2006-01-02"`
+	DryRun    bool   `json:"dry_run"`
+}
+
+// ScheduledSession is a generated session together with the session it
+// would overlap, if any. Conflicting sessions that are part of the same
+// schedule have no ID.
+type ScheduledSession struct {
+	store.Session
+	Conflict *store.Session `json:"conflict,omitempty"`
+}
+
+type ScheduleResponse struct {
+	Sessions  []ScheduledSession `json:"sessions"`
+	Conflicts int                `json:"conflicts"`
+}
+
+// CreateSchedule godoc
+//
+//	@Summary		Generates sessions
+//	@Description	Generates sessions of a movie in one or more rooms for the given days and showtimes. With dry_run
+//	@Description	the sessions are only previewed with their room conflicts; otherwise they are created all at once,
+//	@Description	or not at all if any of them conflicts.
+//	@Tags			sessions
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateSchedulePayload	true	"Schedule"
+//	@Success		200		{object}	ScheduleResponse		"Preview"
+//	@Success		201		{object}	ScheduleResponse		"Created sessions"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	ScheduleResponse	"session_conflict"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/sessions/schedule [post]
+func (app *application) createScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateSchedulePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	loc := app.config.sessions.location
+
+	from, _ := time.ParseInLocation(time.DateOnly, payload.From, loc)
+	to, _ := time.ParseInLocation(time.DateOnly, payload.To, loc)
+	if to.Before(from) {
+		app.badRequestResponse(w, r, fmt.Errorf("to must not be before from"))
+		return
+	}
+	if to.Sub(from) >= maxScheduleDays*24*time.Hour {
+		app.badRequestResponse(w, r, fmt.Errorf("a schedule may span at most %d days", maxScheduleDays))
+		return
+	}
+
+	if payload.Format == "" {
+		payload.Format = store.SessionFormat2D
+	}
+
+	ctx := r.Context()
+
+	movie, err := app.store.Movies.GetByID(ctx, payload.MovieID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	rooms := make([]*store.Room, 0, len(payload.RoomIDs))
+	for _, id := range payload.RoomIDs {
+		room, err := app.store.Rooms.GetByID(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		rooms = append(rooms, room)
+	}
+
+	var sessions []*store.Session
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if len(payload.Days) > 0 && !hasWeekday(payload.Days, day.Weekday()) {
+			continue
+		}
+
+		for _, showtime := range payload.Showtimes {
+			clock, _ := time.Parse("15:04", showtime)
+			start := time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+
+			for _, room := range rooms {
+				sessions = append(sessions, &store.Session{
+					MovieID:   movie.ID,
+					RoomID:    room.ID,
+					StartTime: start.Format(time.RFC3339),
+					Format:    payload.Format,
+					Price:     schedulePrice(payload, day.Weekday(), clock),
+					Prices:    sessionPrices(payload.SeatPrices),
+					Movie:     *movie,
+					Room:      *room,
+				})
+			}
+		}
+	}
+
+	app.schedule(w, r, sessions, payload.DryRun)
+}
+
+// CloneSchedule godoc
+//
+//	@Summary		Copies last week's sessions
+//	@Description	Copies the sessions of a room from the week before week_start into the week starting at week_start,
+//	@Description	keeping their movies, showtimes, formats and prices. dry_run and conflicts work as for generated schedules.
+//	@Tags			sessions
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CloneSchedulePayload	true	"Week to fill"
+//	@Success		200		{object}	ScheduleResponse		"Preview"
+//	@Success		201		{object}	ScheduleResponse		"Created sessions"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	ScheduleResponse	"session_conflict"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/sessions/schedule/clone [post]
+func (app *application) cloneScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CloneSchedulePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	room, err := app.store.Rooms.GetByID(ctx, payload.RoomID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	loc := app.config.sessions.location
+
+	weekStart, _ := time.ParseInLocation(time.DateOnly, payload.WeekStart, loc)
+	lastWeek := weekStart.AddDate(0, 0, -7)
+
+	previous, err := app.store.Sessions.GetByRoom(ctx, room.ID, lastWeek, weekStart)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if len(previous) == 0 {
+		app.notFoundResponse(w, r, fmt.Errorf("room %d has no sessions in the week of %s", room.ID, lastWeek.Format(time.DateOnly)))
+		return
+	}
+
+	sessions := make([]*store.Session, 0, len(previous))
+	for _, session := range previous {
+		start, err := time.Parse(time.RFC3339, session.StartTime)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		// Shift by calendar days so that showtimes keep their local time
+		// across daylight saving changes.
+		start = start.In(loc).AddDate(0, 0, 7)
+
+		sessions = append(sessions, &store.Session{
+			MovieID:   session.MovieID,
+			RoomID:    room.ID,
+			StartTime: start.Format(time.RFC3339),
+			Format:    session.Format,
+			Price:     session.Price,
+			Prices:    session.Prices,
+			Movie:     session.Movie,
+			Room:      *room,
+		})
+	}
+
+	app.schedule(w, r, sessions, payload.DryRun)
+}
+
+// schedule previews the sessions with their conflicts or, unless dryRun is
+// set, creates them all at once if none conflicts.
+func (app *application) schedule(w http.ResponseWriter, r *http.Request, sessions []*store.Session, dryRun bool) {
+	if len(sessions) == 0 {
+		app.badRequestResponse(w, r, fmt.Errorf("the schedule generates no sessions"))
+		return
+	}
+	if len(sessions) > maxScheduleSessions {
+		app.badRequestResponse(w, r, fmt.Errorf("a schedule may generate at most %d sessions, got %d", maxScheduleSessions, len(sessions)))
+		return
+	}
+
+	for _, session := range sessions {
+		if err := app.scheduleSession(session); err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	response, err := app.previewSchedule(r, sessions)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if dryRun {
+		if err := app.jsonResponse(w, http.StatusOK, response); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if response.Conflicts > 0 {
+		app.scheduleConflictResponse(w, r, store.ErrSessionConflict, response)
+		return
+	}
+
+	if err := app.store.Sessions.CreateBatch(r.Context(), sessions); err != nil {
+		switch {
+		case errors.Is(err, store.ErrSessionConflict):
+			// Another session was created since the preview.
+			if response, err = app.previewSchedule(r, sessions); err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+			app.scheduleConflictResponse(w, r, store.ErrSessionConflict, response)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Infow("sessions scheduled", "count", len(sessions), "by", getUserFromCtx(r).ID)
+
+	response = ScheduleResponse{Sessions: make([]ScheduledSession, len(sessions))}
+	for i, session := range sessions {
+		response.Sessions[i] = ScheduledSession{Session: *session}
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, response); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// previewSchedule pairs every session with an existing session it overlaps or,
+// failing that, with an earlier session of the schedule it overlaps.
+func (app *application) previewSchedule(r *http.Request, sessions []*store.Session) (ScheduleResponse, error) {
+	response := ScheduleResponse{Sessions: make([]ScheduledSession, len(sessions))}
+
+	for i, session := range sessions {
+		conflict, err := app.store.Sessions.GetConflicting(r.Context(), session)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return ScheduleResponse{}, err
+		}
+		response.Sessions[i] = ScheduledSession{Session: *session, Conflict: conflict}
+	}
+
+	order := make([]int, len(sessions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		sa, sb := sessions[order[a]], sessions[order[b]]
+		if sa.RoomID != sb.RoomID {
+			return sa.RoomID < sb.RoomID
+		}
+		return sa.StartTime < sb.StartTime
+	})
+
+	// Within a room sorted by start, a session overlaps an earlier one exactly
+	// if it starts before the earlier sessions have all freed the room. The
+	// times are all UTC, so they compare as strings.
+	var last *store.Session
+	for _, i := range order {
+		session := sessions[i]
+		if last != nil && last.RoomID != session.RoomID {
+			last = nil
+		}
+
+		if last != nil && session.StartTime < last.OccupiedUntil && response.Sessions[i].Conflict == nil {
+			conflict := *last
+			response.Sessions[i].Conflict = &conflict
+		}
+
+		if last == nil || last.OccupiedUntil < session.OccupiedUntil {
+			last = session
+		}
+	}
+
+	for _, session := range response.Sessions {
+		if session.Conflict != nil {
+			response.Conflicts++
+		}
+	}
+
+	return response, nil
+}
+
+func schedulePrice(payload CreateSchedulePayload, day time.Weekday, showtime time.Time) float64 {
+	for _, rule := range payload.Rules {
+		if len(rule.Days) > 0 && !hasWeekday(rule.Days, day) {
+			continue
+		}
+		if from, err := time.Parse("15:04", rule.From); err == nil && showtime.Before(from) {
+			continue
+		}
+		if until, err := time.Parse("15:04", rule.Until); err == nil && !showtime.Before(until) {
+			continue
+		}
+		return rule.Price
+	}
+	return payload.Price
+}
+
+func hasWeekday(days []string, day time.Weekday) bool {
+	for _, d := range days {
+		if weekdays[d] == day {
+			return true
+		}
+	}
+	return false
+}
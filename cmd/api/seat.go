@@ -19,10 +19,12 @@ const seatCtx seatKey = "seat"
 //	@RoomID	int64  "Room ID where the seat is located" validate:"required,gte=1"
 //	@Row	int    "Row number of the seat" validate:"required,gte=1"
 //	@Number	int    "Seat number in the row" validate:"required,gte=1"
+//	@Type	string "Seat type, standard if empty" validate:"omitempty,oneof=standard vip love_seat accessible"
 type CreateSeatPayload struct {
-	RoomID int64 `json:"room_id" validate:"required,gte=1"`
-	Row    int64 `json:"row" validate:"required,gte=1"`
-	Number int64 `json:"number" validate:"required,gte=1"`
+	RoomID int64  `json:"room_id" validate:"required,gte=1"`
+	Row    int64  `json:"row" validate:"required,gte=1"`
+	Number int64  `json:"number" validate:"required,gte=1"`
+	Type   string `json:"type" validate:"omitempty,oneof=standard vip love_seat accessible"`
 }
 
 // CreateSeat godoc
@@ -72,6 +74,10 @@ func (app *application) createSeatHandler(w http.ResponseWriter, r *http.Request
 		RoomID: data.Room.ID,
 		Row:    payload.Row,
 		Number: payload.Number,
+		Type:   payload.Type,
+	}
+	if seat.Type == "" {
+		seat.Type = store.SeatTypeStandard
 	}
 
 	if err := app.store.Seats.Create(ctx, seat); err != nil {
@@ -152,9 +158,11 @@ func (app *application) getSeatsBySessionHandler(w http.ResponseWriter, r *http.
 //
 //	@Row	int "Updated row number" validate:"omitempty,gte=1"
 //	@Number	int "Updated seat number" validate:"omitempty,gte=1"
+//	@Type	string "Updated seat type" validate:"omitempty,oneof=standard vip love_seat accessible"
 type UpdateSeatPayload struct {
-	Row    *int64 `json:"row" validate:"omitempty,gte=1"`
-	Number *int64 `json:"seat_number" validate:"omitempty,gte=1"`
+	Row    *int64  `json:"row" validate:"omitempty,gte=1"`
+	Number *int64  `json:"seat_number" validate:"omitempty,gte=1"`
+	Type   *string `json:"type" validate:"omitempty,oneof=standard vip love_seat accessible"`
 }
 
 // UpdateSeat godoc
@@ -194,6 +202,9 @@ func (app *application) updateSeatHandler(w http.ResponseWriter, r *http.Request
 	if payload.Number != nil {
 		seat.Number = *payload.Number
 	}
+	if payload.Type != nil {
+		seat.Type = *payload.Type
+	}
 
 	if err := app.store.Seats.Update(r.Context(), seat); err != nil {
 		switch {
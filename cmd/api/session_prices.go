@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+// SessionPricePayload represents the price of a seat type at a session.
+//
+//	@SeatType	string	"Seat type (standard|vip|love_seat|accessible)"	validate:"required,oneof=standard vip love_seat accessible"
+//	@Price		float64	"Price of every seat of the type"				validate:"gte=0"
+type SessionPricePayload struct {
+	SeatType string  `json:"seat_type" validate:"required,oneof=standard vip love_seat accessible"`
+	Price    float64 `json:"price" validate:"gte=0"`
+}
+
+// SetSessionPricesPayload represents the payload for replacing the seat type
+// prices of a session.
+//
+//	@Prices	[]SessionPricePayload	"Prices by seat type, seat types left out cost the price of the session"
+type SetSessionPricesPayload struct {
+	Prices []SessionPricePayload `json:"prices" validate:"unique=SeatType,dive"`
+}
+
+// SetSessionPrices godoc
+//
+//	@Summary		Sets the seat type prices of a session
+//	@Description	Replaces the prices of the seat types of a session. Seats of a type without a price cost the
+//	@Description	price of the session. Tickets already booked keep their price.
+//	@Tags			sessions
+//	@Accept			json
+//	@Produce		json
+//	@Param			sessionID	path		int						true	"Session ID"
+//	@Param			payload		body		SetSessionPricesPayload	true	"Seat type prices"
+//	@Success		200			{object}	store.Session
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/sessions/{sessionID}/prices [put]
+func (app *application) setSessionPricesHandler(w http.ResponseWriter, r *http.Request) {
+	session := getSessionFromCtx(r)
+
+	if session.Status == store.SessionStatusCancelled {
+		app.badRequestResponse(w, r, store.ErrSessionCancelled)
+		return
+	}
+
+	var payload SetSessionPricesPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	session.Prices = sessionPrices(payload.Prices)
+
+	if err := app.store.Sessions.SetPrices(r.Context(), session.ID, session.Prices); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, session); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+func sessionPrices(payload []SessionPricePayload) []store.SessionPrice {
+	prices := make([]store.SessionPrice, 0, len(payload))
+	for _, p := range payload {
+		prices = append(prices, store.SessionPrice{SeatType: p.SeatType, Price: p.Price})
+	}
+	return prices
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/k5sha/Tikceto/internal/store"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type sessionKey string
@@ -19,12 +20,16 @@ const sessionCtx sessionKey = "session"
 //	@MovieID	int64   "Movie ID for the session" validate:"required,gte=1"`
 //	@RoomID		int64   "Room ID for the session" validate:"required,gte=1"`
 //	@StartTime	date-time   "Start time of the session (timestamp)" validate:"required,iso8601"`
+//	@Format		string  "Screening format, 2D if empty" validate:"omitempty,oneof=2D 3D IMAX 4DX"`
 //	@Price		float64 "Price of the session ticket" validate:"required,gte=0"`
+//	@Prices		[]SessionPricePayload "Prices by seat type, seat types left out cost Price"`
 type CreateSessionPayload struct {
-	MovieID   int64   `json:"movie_id" validate:"required,gte=1"`
-	RoomID    int64   `json:"room_id" validate:"required,gte=1"`
-	StartTime string  `json:"start_time" validate:"required,iso8601"`
-	Price     float64 `json:"price" validate:"required,gte=0"`
+	MovieID   int64                 `json:"movie_id" validate:"required,gte=1"`
+	RoomID    int64                 `json:"room_id" validate:"required,gte=1"`
+	StartTime string                `json:"start_time" validate:"required,iso8601"`
+	Format    string                `json:"format" validate:"omitempty,oneof=2D 3D IMAX 4DX"`
+	Price     float64               `json:"price" validate:"required,gte=0"`
+	Prices    []SessionPricePayload `json:"prices" validate:"unique=SeatType,dive"`
 }
 
 func (app *application) createSessionHandler(w http.ResponseWriter, r *http.Request) {
@@ -65,13 +70,28 @@ func (app *application) createSessionHandler(w http.ResponseWriter, r *http.Requ
 		MovieID:   movie.ID,
 		RoomID:    room.ID,
 		StartTime: payload.StartTime,
+		Format:    payload.Format,
 		Price:     payload.Price,
+		Prices:    sessionPrices(payload.Prices),
 		Movie:     *movie,
 		Room:      *room,
 	}
+	if session.Format == "" {
+		session.Format = store.SessionFormat2D
+	}
+
+	if err := app.scheduleSession(session); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
 
 	if err := app.store.Sessions.Create(ctx, session); err != nil {
-		app.internalServerError(w, r, err)
+		switch {
+		case errors.Is(err, store.ErrSessionConflict):
+			app.sessionConflict(w, r, session, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
 		return
 	}
 
@@ -141,16 +161,123 @@ func (app *application) getSessionsByMovieHandler(w http.ResponseWriter, r *http
 	}
 }
 
+// maxTimetableDays limits how many days the timetable can cover at once.
+const maxTimetableDays = 31
+
+// GetSessions godoc
+//
+//	@Summary		Fetches the timetable
+//	@Description	Fetches the sessions starting between two dates, today by default, ordered by start time.
+//	@Description	Dates are in the local time of the cinema. Every session shows its movie and the seats still available.
+//	@Tags			sessions
+//	@Produce		json
+//	@Param			from		query		string	false	"First day (YYYY-MM-DD)"
+//	@Param			to			query		string	false	"Last day (YYYY-MM-DD)"
+//	@Param			room_id		query		int		false	"Room ID"
+//	@Param			movie_id	query		int		false	"Movie ID"
+//	@Param			format		query		string	false	"Screening format (2D|3D|IMAX|4DX)"
+//	@Param			min_price	query		number	false	"Lowest price"
+//	@Param			max_price	query		number	false	"Highest price"
+//	@Param			limit		query		int		false	"Limit"
+//	@Param			offset		query		int		false	"Offset"
+//	@Success		200			{object}	store.PaginatedSessionsResponse
+//	@Failure		400			{object}	error
+//	@Failure		500			{object}	error
+//	@Router			/sessions [get]
+func (app *application) getSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	pq := store.PaginatedSessionsQuery{
+		Limit:  50,
+		Offset: 0,
+	}
+
+	pq, err := pq.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(pq); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	loc := app.config.sessions.location
+
+	now := time.Now().In(loc)
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if pq.From != "" {
+		since, _ = time.ParseInLocation(time.DateOnly, pq.From, loc)
+	}
+
+	until := since
+	if pq.To != "" {
+		until, _ = time.ParseInLocation(time.DateOnly, pq.To, loc)
+	}
+
+	if until.Before(since) {
+		app.badRequestResponse(w, r, fmt.Errorf("to must not be before from"))
+		return
+	}
+	if until.Sub(since) >= maxTimetableDays*24*time.Hour {
+		app.badRequestResponse(w, r, fmt.Errorf("the timetable covers at most %d days", maxTimetableDays))
+		return
+	}
+
+	if pq.MinPrice != nil && pq.MaxPrice != nil && *pq.MaxPrice < *pq.MinPrice {
+		app.badRequestResponse(w, r, fmt.Errorf("max_price must not be below min_price"))
+		return
+	}
+
+	pq.Since = since
+	pq.Until = until.AddDate(0, 0, 1)
+
+	sessions, total, err := app.store.Sessions.GetTimetable(r.Context(), pq)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// A movie usually has many sessions a day, so sign each poster once.
+	posters := make(map[string]string)
+	for i := range sessions {
+		poster := sessions[i].Movie.PosterUrl
+
+		url, ok := posters[poster]
+		if !ok {
+			url, err = app.s3.GetOne(poster)
+			if err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+			posters[poster] = url
+		}
+
+		sessions[i].Movie.PosterUrl = url
+	}
+
+	response := store.PaginatedSessionsResponse{
+		Data:  sessions,
+		Total: total,
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
 // UpdateSessionPayload represents the payload for updating a session.
 //
 //	@MovieID	int64   "Updated movie ID for the session" validate:"omitempty,gte=1"`
 //	@RoomID		int64   "Updated room ID for the session" validate:"omitempty,gte=1"`
-//	@StartTime	date-time   "Updated start time of the session" validate:"omitempty,datetime=2006-01-02 15:04:05"`
+//	@StartTime	date-time   "Updated start time of the session, UTC unless it has a time zone" validate:"omitempty,iso8601|datetime=2006-01-02 15:04:05"`
+//	@Format		string  "Updated screening format" validate:"omitempty,oneof=2D 3D IMAX 4DX"`
 //	@Price		float64 "Updated price of the session" validate:"omitempty,gte=0"`
 type UpdateSessionPayload struct {
 	MovieID   *int64   `json:"movie_id" validate:"omitempty,gte=1"`
 	RoomID    *int64   `json:"room_id" validate:"omitempty,gte=1"`
-	StartTime *string  `json:"start_time" validate:"omitempty,datetime=2006-01-02 15:04:05"`
+	StartTime *string  `json:"start_time" validate:"omitempty,iso8601|datetime=2006-01-02 15:04:05"`
+	Format    *string  `json:"format" validate:"omitempty,oneof=2D 3D IMAX 4DX"`
 	Price     *float64 `json:"price" validate:"omitempty,gte=0"`
 }
 
@@ -167,12 +294,18 @@ type UpdateSessionPayload struct {
 //	@Failure		400		{object}	error
 //	@Failure		401		{object}	error
 //	@Failure		404		{object}	error
+//	@Failure		409		{object}	error	"session_conflict"
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/sessions/{id} [patch]
 func (app *application) updateSessionHandler(w http.ResponseWriter, r *http.Request) {
 	session := getSessionFromCtx(r)
 
+	if session.Status == store.SessionStatusCancelled {
+		app.badRequestResponse(w, r, store.ErrSessionCancelled)
+		return
+	}
+
 	var payload UpdateSessionPayload
 
 	if err := readJSON(w, r, &payload); err != nil {
@@ -198,6 +331,7 @@ func (app *application) updateSessionHandler(w http.ResponseWriter, r *http.Requ
 			return
 		}
 		session.MovieID = movie.ID
+		session.Movie = *movie
 	}
 	if payload.RoomID != nil {
 		room, err := app.store.Rooms.GetByID(ctx, *payload.RoomID)
@@ -210,18 +344,29 @@ func (app *application) updateSessionHandler(w http.ResponseWriter, r *http.Requ
 			return
 		}
 		session.RoomID = room.ID
+		session.Room = *room
 	}
 	if payload.StartTime != nil {
 		session.StartTime = *payload.StartTime
 	}
+	if payload.Format != nil {
+		session.Format = *payload.Format
+	}
 	if payload.Price != nil {
 		session.Price = *payload.Price
 	}
 
+	if err := app.scheduleSession(session); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
 	if err := app.store.Sessions.Update(r.Context(), session); err != nil {
 		switch {
 		case errors.Is(err, store.ErrNotFound):
 			app.notFoundResponse(w, r, err)
+		case errors.Is(err, store.ErrSessionConflict):
+			app.sessionConflict(w, r, session, err)
 		default:
 			app.internalServerError(w, r, err)
 		}
@@ -238,13 +383,14 @@ func (app *application) updateSessionHandler(w http.ResponseWriter, r *http.Requ
 // DeleteSession godoc
 //
 //	@Summary		Deletes a session
-//	@Description	Delete a session by ID
+//	@Description	Delete a session by ID. Sessions with tickets cannot be deleted and must be cancelled instead.
 //	@Tags			sessions
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		int	true	"Session ID"
 //	@Success		204	{object}	string
 //	@Failure		404	{object}	error
+//	@Failure		409	{object}	error
 //	@Failure		500	{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/sessions/{id} [delete]
@@ -256,6 +402,8 @@ func (app *application) deleteSessionHandler(w http.ResponseWriter, r *http.Requ
 		switch {
 		case errors.Is(err, store.ErrNotFound):
 			app.notFoundResponse(w, r, err)
+		case errors.Is(err, store.ErrSessionHasTickets):
+			app.conflictResponse(w, r, err)
 		default:
 			app.internalServerError(w, r, err)
 		}
@@ -269,6 +417,44 @@ func (app *application) deleteSessionHandler(w http.ResponseWriter, r *http.Requ
 
 }
 
+// scheduleSession works out when the session ends from the duration of its
+// movie and how long the room stays occupied after it, including cleaning.
+func (app *application) scheduleSession(session *store.Session) error {
+	start, err := parseSessionTime(session.StartTime)
+	if err != nil {
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+
+	end := start.Add(time.Duration(session.Movie.Duration) * time.Minute)
+
+	session.StartTime = start.UTC().Format(time.RFC3339)
+	session.EndTime = end.UTC().Format(time.RFC3339)
+	session.OccupiedUntil = end.Add(app.config.sessions.cleaningBuffer).UTC().Format(time.RFC3339)
+
+	return nil
+}
+
+// parseSessionTime accepts RFC 3339 times as well as times without a time
+// zone, which are taken as UTC.
+func parseSessionTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateTime, value, time.UTC)
+}
+
+// sessionConflict responds that the room of session is already booked,
+// naming the session that occupies it.
+func (app *application) sessionConflict(w http.ResponseWriter, r *http.Request, session *store.Session, err error) {
+	conflict, lookupErr := app.store.Sessions.GetConflicting(r.Context(), session)
+	if lookupErr != nil && !errors.Is(lookupErr, store.ErrNotFound) {
+		app.internalServerError(w, r, lookupErr)
+		return
+	}
+
+	app.sessionConflictResponse(w, r, err, conflict)
+}
+
 func (app *application) sessionsContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		idParam := chi.URLParam(r, "sessionID")
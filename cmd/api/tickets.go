@@ -30,7 +30,7 @@ type CreateTicketPayload struct {
 // CreateTicket godoc
 //
 //	@Summary		Creates a ticket
-//	@Description	Creates a ticket
+//	@Description	Issues a confirmed ticket that is not part of an order
 //	@Tags			tickets
 //	@Accept			json
 //	@Produce		json
@@ -80,17 +80,22 @@ func (app *application) createTicketHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if session.Status == store.SessionStatusCancelled {
+		app.badRequestResponse(w, r, store.ErrSessionCancelled)
+		return
+	}
+
 	ticket := &store.Ticket{
 		SessionID: session.ID,
 		SeatID:    seat.ID,
-		UserID:    payload.UserID,
+		UserID:    &payload.UserID,
 		Price:     payload.Price,
 		Session:   *session,
 		Seat:      *seat,
 	}
 
 	if err := app.store.Tickets.Create(ctx, ticket); err != nil {
-		if errors.Is(err, store.ErrDuplicateTicket) {
+		if errors.Is(err, store.ErrDuplicateTicket) || errors.Is(err, store.ErrSessionCancelled) {
 			app.badRequestResponse(w, r, err)
 			return
 		}
@@ -280,13 +285,17 @@ func (app *application) updateTicketHandler(w http.ResponseWriter, r *http.Reque
 			app.internalServerError(w, r, err)
 			return
 		}
-		ticket.UserID = user.ID
+		ticket.UserID = &user.ID
 	}
 	if payload.Price != nil {
 		ticket.Price = *payload.Price
 	}
 
-	if payload.Status != nil {
+	if payload.Status != nil && *payload.Status != ticket.Status {
+		if !store.CanTransitionTicket(ticket.Status, *payload.Status) {
+			app.badRequestResponse(w, r, fmt.Errorf("cannot change ticket status from %q to %q", ticket.Status, *payload.Status))
+			return
+		}
 		ticket.Status = *payload.Status
 	}
 
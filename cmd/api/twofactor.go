@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/k5sha/Tikceto/internal/auth"
+	"github.com/k5sha/Tikceto/internal/eticket"
+	"github.com/k5sha/Tikceto/internal/store"
+)
+
+const (
+	recoveryCodeCount = 10
+	twoFactorQRSize   = 256
+)
+
+var errInvalidSecondFactor = errors.New("invalid two-factor code")
+
+// TwoFactorChallengeResponse is returned instead of tokens when the user has
+// to confirm the sign-in with a second factor.
+type TwoFactorChallengeResponse struct {
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresIn      int64  `json:"expires_in"`
+}
+
+// TwoFactorEnrollment holds the secret to add to an authenticator app.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QRCode string `json:"qr_code"`
+}
+
+// RecoveryCodesResponse lists one-time recovery codes. They are shown only
+// once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorCodePayload carries a code from the authenticator app or, when
+// signing in or disabling two-factor authentication, a recovery code.
+//
+//	@Code			string "The six-digit code from the authenticator app" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"
+//	@RecoveryCode	string "A recovery code" validate:"omitempty,max=32"
+type TwoFactorCodePayload struct {
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"omitempty,max=32"`
+}
+
+// TwoFactorTokenPayload represents the second step of signing in.
+//
+//	@ChallengeToken	string "The challenge token from /authentication/token" validate:"required"
+type TwoFactorTokenPayload struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	TwoFactorCodePayload
+}
+
+// enrollTwoFactorHandler godoc
+//
+//	@Summary		Starts two-factor enrollment
+//	@Description	Creates a new TOTP secret for the current user. Two-factor authentication is enabled once a code
+//	@Description	from the authenticator app is confirmed at /users/me/2fa/verify.
+//	@Tags			users
+//	@Produce		json
+//	@Success		201	{object}	TwoFactorEnrollment
+//	@Failure		401	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/2fa [post]
+func (app *application) enrollTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.store.TwoFactor.Enroll(r.Context(), user.ID, secret); err != nil {
+		switch {
+		case errors.Is(err, store.ErrTwoFactorEnabled):
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	uri := auth.TOTPURI(app.config.auth.twoFactor.issuer, user.Email, secret)
+
+	qr, err := eticket.PNG(uri, twoFactorQRSize)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	enrollment := TwoFactorEnrollment{
+		Secret: secret,
+		URI:    uri,
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(qr),
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, enrollment); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// enableTwoFactorHandler godoc
+//
+//	@Summary		Enables two-factor authentication
+//	@Description	Confirms the enrollment with a code from the authenticator app and returns recovery codes
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		TwoFactorCodePayload	true	"Authenticator code"
+//	@Success		200		{object}	RecoveryCodesResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/2fa/verify [post]
+func (app *application) enableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	var payload TwoFactorCodePayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(&payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromCtx(r)
+	ctx := r.Context()
+
+	twoFactor, err := app.store.TwoFactor.Get(ctx, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if twoFactor.Enabled {
+		app.conflictResponse(w, r, store.ErrTwoFactorEnabled)
+		return
+	}
+
+	counter, ok := auth.ValidateTOTP(twoFactor.Secret, payload.Code, time.Now())
+	if !ok {
+		app.invalidTwoFactorCodeResponse(w, r, errInvalidSecondFactor)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.store.TwoFactor.Enable(ctx, user.ID, counter, hashes); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// disableTwoFactorHandler godoc
+//
+//	@Summary		Disables two-factor authentication
+//	@Description	Disables two-factor authentication of the current user after checking a code or a recovery code
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		TwoFactorCodePayload	true	"Authenticator or recovery code"
+//	@Success		204		{string}	string					"Two-factor authentication disabled"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		429		{object}	error	"account_locked"
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/2fa [delete]
+func (app *application) disableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	var payload TwoFactorCodePayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(&payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromCtx(r)
+	ctx := r.Context()
+
+	if !app.checkSecondFactor(w, r, user.ID, payload) {
+		return
+	}
+
+	if err := app.store.TwoFactor.Disable(ctx, user.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// createTwoFactorTokenHandler godoc
+//
+//	@Summary		Completes a two-factor sign-in
+//	@Description	Exchanges a challenge token and a code from the authenticator app, or a recovery code, for tokens
+//	@Tags			authentication
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		TwoFactorTokenPayload	true	"Challenge and code"
+//	@Success		201		{object}	TokenResponse			"Tokens"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error	"invalid_two_factor_code"
+//	@Failure		429		{object}	error	"account_locked"
+//	@Failure		500		{object}	error
+//	@Router			/authentication/token/2fa [post]
+func (app *application) createTwoFactorTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload TwoFactorTokenPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(&payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	userID, err := app.parseTwoFactorChallenge(payload.ChallengeToken)
+	if err != nil {
+		app.unauthorizedErrorResponse(w, r, err)
+		return
+	}
+
+	if !app.checkSecondFactor(w, r, userID, payload.TwoFactorCodePayload) {
+		return
+	}
+
+	tokens, err := app.startSession(r.Context(), userID, true)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, tokens); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// issueTwoFactorChallenge signs a short-lived token proving that the user
+// passed the password check. It has no session, so it is not accepted as an
+// access token.
+func (app *application) issueTwoFactorChallenge(userID int64) (*TwoFactorChallengeResponse, error) {
+	now := time.Now()
+	exp := app.config.auth.twoFactor.challengeExp
+
+	claims := jwt.MapClaims{
+		"sub": userID,
+		"typ": "2fa",
+		"exp": now.Add(exp).Unix(),
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"iss": app.config.auth.token.iss,
+		"aud": app.config.auth.token.iss,
+	}
+	token, err := app.authenticator.GenerateToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorChallengeResponse{
+		ChallengeToken: token,
+		ExpiresIn:      int64(exp.Seconds()),
+	}, nil
+}
+
+func (app *application) parseTwoFactorChallenge(token string) (int64, error) {
+	jwtToken, err := app.authenticator.ValidateToken(token)
+	if err != nil {
+		return 0, err
+	}
+
+	claims, _ := jwtToken.Claims.(jwt.MapClaims)
+	if claims["typ"] != "2fa" {
+		return 0, fmt.Errorf("not a two-factor challenge token")
+	}
+
+	return strconv.ParseInt(fmt.Sprintf("%.f", claims["sub"]), 10, 64)
+}
+
+// checkSecondFactor verifies the code in the payload, counting wrong codes
+// towards a lockout of the user. It writes the error response and returns
+// false if the code is not accepted.
+func (app *application) checkSecondFactor(w http.ResponseWriter, r *http.Request, userID int64, payload TwoFactorCodePayload) bool {
+	ctx := r.Context()
+	key := strconv.FormatInt(userID, 10)
+
+	retryAfter, err := app.logins.twoFactor.RetryAfter(ctx, key)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return false
+	}
+
+	if retryAfter > 0 {
+		app.tooManyAttemptsResponse(w, r, errCodeAccountLocked, retryAfter)
+		return false
+	}
+
+	if err := app.verifySecondFactor(ctx, userID, payload); err != nil {
+		switch {
+		case errors.Is(err, errInvalidSecondFactor):
+			if _, _, err := app.logins.twoFactor.Fail(ctx, key); err != nil {
+				app.logger.Errorw("error recording failed two-factor code", "user", userID, "error", err)
+			}
+			app.invalidTwoFactorCodeResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return false
+	}
+
+	if err := app.logins.twoFactor.Reset(ctx, key); err != nil {
+		app.logger.Errorw("error resetting failed two-factor codes", "user", userID, "error", err)
+	}
+
+	return true
+}
+
+// verifySecondFactor checks a TOTP code, or spends a recovery code, of a user
+// with two-factor authentication enabled. It returns errInvalidSecondFactor
+// if the code is wrong or was already used.
+func (app *application) verifySecondFactor(ctx context.Context, userID int64, payload TwoFactorCodePayload) error {
+	twoFactor, err := app.store.TwoFactor.Get(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return errInvalidSecondFactor
+		default:
+			return err
+		}
+	}
+
+	if !twoFactor.Enabled {
+		return errInvalidSecondFactor
+	}
+
+	if payload.RecoveryCode != "" {
+		err := app.store.TwoFactor.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(payload.RecoveryCode)))
+		if errors.Is(err, store.ErrNotFound) {
+			return errInvalidSecondFactor
+		}
+		return err
+	}
+
+	counter, ok := auth.ValidateTOTP(twoFactor.Secret, payload.Code, time.Now())
+	if !ok {
+		return errInvalidSecondFactor
+	}
+
+	err = app.store.TwoFactor.UseCode(ctx, userID, counter)
+	if errors.Is(err, store.ErrCodeUsed) {
+		return errInvalidSecondFactor
+	}
+	return err
+}
+
+// newRecoveryCodes returns a set of plain recovery codes for the user and
+// their hashes for storage.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = code[:8] + "-" + code[8:]
+		hashes[i] = hashToken(code)
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -11,7 +11,10 @@ import (
 
 type userKey string
 
-const userCtx userKey = "user"
+const (
+	userCtx        userKey = "user"
+	authSessionCtx userKey = "authSession"
+)
 
 // ActivateUser godoc
 //
@@ -22,6 +25,7 @@ const userCtx userKey = "user"
 //	@Param			token	path		string	true	"Invitation token"
 //	@Success		204		{string}	string	"User activated"
 //	@Failure		404		{object}	error
+//	@Failure		410		{object}	error	"activation_token_expired"
 //	@Failure		500		{object}	error
 //	@Router			/users/activate/{token} [put]
 func (app *application) activateUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -35,6 +39,8 @@ func (app *application) activateUserHandler(w http.ResponseWriter, r *http.Reque
 		switch {
 		case errors.Is(err, store.ErrNotFound):
 			app.notFoundResponse(w, r, err)
+		case errors.Is(err, store.ErrTokenExpired):
+			app.activationTokenExpiredResponse(w, r, err)
 		default:
 			app.internalServerError(w, r, err)
 		}
@@ -81,3 +87,14 @@ func getUserFromCtx(r *http.Request) *store.User {
 func (app *application) getUser(ctx context.Context, userID int64) (*store.User, error) {
 	return app.store.Users.GetByID(ctx, userID)
 }
+
+// authSession is the login session an access token was issued for.
+type authSession struct {
+	ID  string
+	MFA bool
+}
+
+func getAuthSessionFromCtx(r *http.Request) *authSession {
+	session, _ := r.Context().Value(authSessionCtx).(*authSession)
+	return session
+}
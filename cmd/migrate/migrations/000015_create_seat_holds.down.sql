@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS seat_holds;
+
+DROP INDEX IF EXISTS tickets_session_id_seat_id_key;
+
+DELETE FROM tickets WHERE status NOT IN ('pending', 'confirmed');
+
+ALTER TABLE tickets
+    ADD CONSTRAINT tickets_session_id_seat_id_key UNIQUE (session_id, seat_id);
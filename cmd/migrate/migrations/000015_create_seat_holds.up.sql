@@ -0,0 +1,13 @@
+ALTER TABLE tickets DROP CONSTRAINT IF EXISTS tickets_session_id_seat_id_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tickets_session_id_seat_id_key
+    ON tickets (session_id, seat_id)
+    WHERE status IN ('pending', 'confirmed');
+
+CREATE TABLE IF NOT EXISTS seat_holds (
+    ticket_id uuid PRIMARY KEY REFERENCES tickets(id) ON DELETE CASCADE,
+    expires_at timestamp(0) with time zone NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS seat_holds_expires_at_idx ON seat_holds (expires_at);
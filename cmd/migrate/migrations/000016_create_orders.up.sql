@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id bigint REFERENCES users(id) ON DELETE SET NULL,
+    amount decimal(10, 2) NOT NULL CHECK (amount >= 0),
+    currency varchar(3) NOT NULL DEFAULT 'UAH',
+    status varchar(50) NOT NULL DEFAULT 'pending',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE tickets
+    ADD COLUMN order_id uuid REFERENCES orders(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS tickets_order_id_idx ON tickets (order_id);
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS payments (
+    id bigserial PRIMARY KEY,
+    order_id uuid NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+    provider varchar(50) NOT NULL,
+    provider_order_id varchar(255) NOT NULL,
+    amount decimal(10, 2) NOT NULL CHECK (amount >= 0),
+    currency varchar(3) NOT NULL,
+    status varchar(50) NOT NULL DEFAULT 'pending',
+    checkout_url text,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    UNIQUE (provider, provider_order_id)
+);
+
+CREATE INDEX IF NOT EXISTS payments_order_id_idx ON payments (order_id);
+
+CREATE TABLE IF NOT EXISTS payment_events (
+    id bigserial PRIMARY KEY,
+    payment_id bigint REFERENCES payments(id) ON DELETE RESTRICT,
+    provider varchar(50) NOT NULL,
+    provider_order_id varchar(255),
+    provider_transaction_id varchar(255),
+    provider_status varchar(50),
+    amount decimal(10, 2),
+    currency varchar(3),
+    signature_valid boolean NOT NULL,
+    from_status varchar(50),
+    to_status varchar(50),
+    raw_payload text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS payment_events_payment_id_idx ON payment_events (payment_id);
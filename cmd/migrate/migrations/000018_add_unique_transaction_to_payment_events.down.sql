@@ -0,0 +1 @@
+DROP INDEX IF EXISTS payment_events_provider_transaction_key;
@@ -0,0 +1,3 @@
+CREATE UNIQUE INDEX IF NOT EXISTS payment_events_provider_transaction_key
+    ON payment_events (provider, provider_transaction_id, provider_status)
+    WHERE provider_transaction_id IS NOT NULL AND signature_valid;
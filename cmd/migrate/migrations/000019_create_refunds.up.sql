@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS refunds (
+    id bigserial PRIMARY KEY,
+    payment_id bigint NOT NULL REFERENCES payments(id) ON DELETE RESTRICT,
+    ticket_id uuid REFERENCES tickets(id) ON DELETE SET NULL,
+    amount decimal(10, 2) NOT NULL CHECK (amount >= 0),
+    status varchar(50) NOT NULL DEFAULT 'pending',
+    reason text,
+    provider_response text,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS refunds_payment_id_idx ON refunds (payment_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS refunds_ticket_id_key
+    ON refunds (ticket_id)
+    WHERE status <> 'failed';
+
+CREATE UNIQUE INDEX IF NOT EXISTS refunds_payment_id_key
+    ON refunds (payment_id)
+    WHERE ticket_id IS NULL AND status <> 'failed';
@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS tickets_session_id_seat_id_key;
+
+UPDATE tickets SET status = 'confirmed' WHERE status = 'used';
+
+CREATE UNIQUE INDEX IF NOT EXISTS tickets_session_id_seat_id_key
+    ON tickets (session_id, seat_id)
+    WHERE status IN ('pending', 'confirmed');
+
+ALTER TABLE tickets
+    DROP COLUMN IF EXISTS checked_in_by,
+    DROP COLUMN IF EXISTS used_at;
+
+UPDATE users SET role_id = (SELECT id FROM roles WHERE name = 'user')
+WHERE role_id = (SELECT id FROM roles WHERE name = 'usher');
+
+DELETE FROM roles WHERE name = 'usher';
+
+UPDATE roles SET level = 2 WHERE name = 'admin';
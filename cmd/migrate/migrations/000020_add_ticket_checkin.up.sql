@@ -0,0 +1,15 @@
+UPDATE roles SET level = 3 WHERE name = 'admin';
+
+INSERT INTO roles (name, description, level)
+VALUES ('usher', 'An usher can check tickets in at the door', 2)
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE tickets
+    ADD COLUMN IF NOT EXISTS used_at timestamp(0) with time zone,
+    ADD COLUMN IF NOT EXISTS checked_in_by bigint REFERENCES users(id) ON DELETE SET NULL;
+
+DROP INDEX IF EXISTS tickets_session_id_seat_id_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tickets_session_id_seat_id_key
+    ON tickets (session_id, seat_id)
+    WHERE status IN ('pending', 'confirmed', 'used');
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id text PRIMARY KEY,
+    algorithm varchar(16) NOT NULL,
+    private_key bytea NOT NULL,
+    not_before timestamp(0) with time zone NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS signing_keys_not_before_idx ON signing_keys (not_before);
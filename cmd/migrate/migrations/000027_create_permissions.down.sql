@@ -0,0 +1,4 @@
+ALTER TABLE roles DROP COLUMN IF EXISTS requires_mfa;
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS permissions (
+    id bigserial PRIMARY KEY,
+    name varchar(100) NOT NULL UNIQUE,
+    description text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id bigint NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id bigint NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS requires_mfa boolean NOT NULL DEFAULT false;
+
+UPDATE roles SET requires_mfa = true WHERE name = 'admin';
+
+INSERT INTO permissions (name, description) VALUES
+    ('rooms:write', 'Create, update and delete rooms'),
+    ('seats:write', 'Create, update and delete seats'),
+    ('movies:write', 'Create, update and delete movies'),
+    ('sessions:write', 'Create, update and delete sessions'),
+    ('tickets:read', 'View any ticket'),
+    ('tickets:write', 'Sell, update and delete tickets'),
+    ('tickets:checkin', 'Check tickets in at the door'),
+    ('payments:read', 'View payments and reconciliation reports'),
+    ('users:manage', 'Manage user accounts'),
+    ('roles:manage', 'Manage roles and their permissions')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p ON p.name = 'tickets:checkin'
+WHERE r.name = 'usher'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS banned_at timestamp(0) with time zone,
+    ADD COLUMN IF NOT EXISTS ban_reason text;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS account_deletions;
+
+CREATE SEQUENCE IF NOT EXISTS tickets_user_id_seq OWNED BY tickets.user_id;
+ALTER TABLE tickets ALTER COLUMN user_id SET DEFAULT nextval('tickets_user_id_seq');
+ALTER TABLE tickets ALTER COLUMN user_id SET NOT NULL;
@@ -0,0 +1,14 @@
+-- tickets.user_id was created as bigserial, which made it NOT NULL and broke
+-- its ON DELETE SET NULL; tickets of deleted users must be kept.
+ALTER TABLE tickets ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE tickets ALTER COLUMN user_id DROP NOT NULL;
+DROP SEQUENCE IF EXISTS tickets_user_id_seq;
+
+CREATE TABLE IF NOT EXISTS account_deletions (
+    token bytea PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expiry timestamp(0) with time zone NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS account_deletions_user_id_idx ON account_deletions (user_id);
@@ -0,0 +1,5 @@
+ALTER TABLE sessions
+    DROP CONSTRAINT IF EXISTS sessions_room_time_excl,
+    DROP CONSTRAINT IF EXISTS sessions_time_check,
+    DROP COLUMN IF EXISTS occupied_until,
+    DROP COLUMN IF EXISTS end_time;
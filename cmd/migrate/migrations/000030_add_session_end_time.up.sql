@@ -0,0 +1,26 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- end_time is when the movie ends, occupied_until is when the room is free
+-- again after cleaning.
+ALTER TABLE sessions
+    ADD COLUMN end_time timestamp(0) with time zone,
+    ADD COLUMN occupied_until timestamp(0) with time zone;
+
+UPDATE sessions s
+SET end_time = s.start_time + make_interval(mins => m.duration),
+    occupied_until = s.start_time + make_interval(mins => m.duration)
+FROM movies m
+WHERE m.id = s.movie_id;
+
+ALTER TABLE sessions
+    ALTER COLUMN end_time SET NOT NULL,
+    ALTER COLUMN occupied_until SET NOT NULL,
+    ADD CONSTRAINT sessions_time_check CHECK (start_time < end_time AND end_time <= occupied_until);
+
+-- Fails if the room is already double-booked; move or delete the overlapping
+-- sessions first.
+ALTER TABLE sessions
+    ADD CONSTRAINT sessions_room_time_excl EXCLUDE USING gist (
+        room_id WITH =,
+        tstzrange(start_time, occupied_until) WITH &&
+    );
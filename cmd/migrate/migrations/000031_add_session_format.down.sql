@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS sessions_start_time_idx;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS format;
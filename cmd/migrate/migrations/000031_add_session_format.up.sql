@@ -0,0 +1,3 @@
+ALTER TABLE sessions ADD COLUMN format varchar(20) NOT NULL DEFAULT '2D';
+
+CREATE INDEX IF NOT EXISTS sessions_start_time_idx ON sessions (start_time);
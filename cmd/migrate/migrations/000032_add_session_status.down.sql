@@ -0,0 +1,13 @@
+-- Fails if a cancelled session overlaps another session of its room; delete
+-- or move it first.
+ALTER TABLE sessions
+    DROP CONSTRAINT IF EXISTS sessions_room_time_excl,
+    ADD CONSTRAINT sessions_room_time_excl EXCLUDE USING gist (
+        room_id WITH =,
+        tstzrange(start_time, occupied_until) WITH &&
+    );
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS cancellation_reason,
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,12 @@
+ALTER TABLE sessions
+    ADD COLUMN status varchar(20) NOT NULL DEFAULT 'scheduled',
+    ADD COLUMN cancelled_at timestamp(0) with time zone,
+    ADD COLUMN cancellation_reason text;
+
+-- A cancelled session no longer occupies its room.
+ALTER TABLE sessions
+    DROP CONSTRAINT IF EXISTS sessions_room_time_excl,
+    ADD CONSTRAINT sessions_room_time_excl EXCLUDE USING gist (
+        room_id WITH =,
+        tstzrange(start_time, occupied_until) WITH &&
+    ) WHERE (status <> 'cancelled');
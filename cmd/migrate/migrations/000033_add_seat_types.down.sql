@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS session_prices;
+
+ALTER TABLE seats DROP COLUMN IF EXISTS type;
@@ -0,0 +1,12 @@
+ALTER TABLE seats
+    ADD COLUMN type varchar(20) NOT NULL DEFAULT 'standard'
+        CHECK (type IN ('standard', 'vip', 'love_seat', 'accessible'));
+
+-- Prices of the seat types of a session. Seats of a type without a price here
+-- cost sessions.price.
+CREATE TABLE IF NOT EXISTS session_prices (
+    session_id bigint NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    seat_type varchar(20) NOT NULL,
+    price decimal(10, 2) NOT NULL CHECK (price >= 0),
+    PRIMARY KEY (session_id, seat_type)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS level int NOT NULL DEFAULT 0;
+
+UPDATE roles SET level = 1 WHERE name = 'user';
+UPDATE roles SET level = 2 WHERE name = 'usher';
+UPDATE roles SET level = 3 WHERE name = 'admin';
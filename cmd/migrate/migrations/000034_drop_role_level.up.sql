@@ -0,0 +1,2 @@
+-- Roles are compared by their permissions, the level is no longer used.
+ALTER TABLE roles DROP COLUMN IF EXISTS level;
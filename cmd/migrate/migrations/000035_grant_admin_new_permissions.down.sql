@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS permissions_grant_to_admin ON permissions;
+
+DROP FUNCTION IF EXISTS grant_permission_to_admin();
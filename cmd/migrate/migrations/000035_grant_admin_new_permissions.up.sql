@@ -0,0 +1,18 @@
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
+
+-- The admin role holds every permission, including the ones added later.
+CREATE OR REPLACE FUNCTION grant_permission_to_admin() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO role_permissions (role_id, permission_id)
+    SELECT id, NEW.id FROM roles WHERE name = 'admin'
+    ON CONFLICT DO NOTHING;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER permissions_grant_to_admin
+    AFTER INSERT ON permissions
+    FOR EACH ROW EXECUTE PROCEDURE grant_permission_to_admin();
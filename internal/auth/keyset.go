@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrNoSigningKey = errors.New("no signing key available")
+
+// KeySource is implemented by authenticators whose tokens can be verified
+// with public keys only.
+type KeySource interface {
+	JWKS() JWKS
+}
+
+// KeySetAuthenticator signs tokens with asymmetric keys. It signs with one key
+// at a time but accepts tokens signed by any key of the set, so keys can be
+// rotated without invalidating tokens that are still in use.
+type KeySetAuthenticator struct {
+	aud string
+	iss string
+
+	mu      sync.RWMutex
+	signing *Key
+	keys    []*Key
+}
+
+func NewKeySetAuthenticator(aud, iss string) *KeySetAuthenticator {
+	return &KeySetAuthenticator{aud: aud, iss: iss}
+}
+
+// SetKeys replaces the key set. New tokens are signed with signing, which
+// must be one of keys.
+func (a *KeySetAuthenticator) SetKeys(signing *Key, keys []*Key) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.signing = signing
+	a.keys = keys
+}
+
+func (a *KeySetAuthenticator) key(id string) *Key {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, key := range a.keys {
+		if key.ID == id {
+			return key
+		}
+	}
+	return nil
+}
+
+func (a *KeySetAuthenticator) GenerateToken(claims jwt.Claims) (string, error) {
+	a.mu.RLock()
+	key := a.signing
+	a.mu.RUnlock()
+
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+
+	tokenString, err := token.SignedString(key.Signer)
+	if err != nil {
+		return "", err
+	}
+
+	return tokenString, nil
+}
+
+func (a *KeySetAuthenticator) ValidateToken(token string) (*jwt.Token, error) {
+	return jwt.Parse(token, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		key := a.key(kid)
+		if key == nil {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+
+		if t.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+
+		return key.Signer.Public(), nil
+	},
+		jwt.WithExpirationRequired(),
+		jwt.WithAudience(a.aud),
+		jwt.WithIssuer(a.iss),
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}),
+	)
+}
+
+// JWKS returns the public keys of the set.
+func (a *KeySetAuthenticator) JWKS() JWKS {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(a.keys))}
+	for _, key := range a.keys {
+		jwks.Keys = append(jwks.Keys, key.JWK())
+	}
+
+	return jwks
+}
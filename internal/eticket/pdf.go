@@ -0,0 +1,118 @@
+package eticket
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// Ticket is what gets printed on a PDF ticket. StartTime is printed in its own
+// location.
+type Ticket struct {
+	ID        string
+	Code      string
+	Movie     string
+	Room      string
+	Row       int64
+	Seat      int64
+	StartTime time.Time
+	Price     float64
+	Currency  string
+	// Poster is an optional JPEG, PNG or GIF image. Other formats are left
+	// out of the ticket.
+	Poster []byte
+}
+
+// PDF renders a printable A5 ticket with its QR code.
+func PDF(t Ticket) ([]byte, error) {
+	qr, err := PNG(t.Code, 512)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "mm", "A5", "")
+	pdf.SetTitle(t.Movie, true)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddUTF8FontFromBytes("go", "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes("go", "B", gobold.TTF)
+	pdf.AddPage()
+
+	const margin = 12.0
+	pageWidth, _ := pdf.GetPageSize()
+	textX := margin
+
+	if imageType := posterType(t.Poster); imageType != "" {
+		opts := fpdf.ImageOptions{ImageType: imageType}
+		info := pdf.RegisterImageOptionsReader("poster", opts, bytes.NewReader(t.Poster))
+
+		// A broken poster should not cost the customer their ticket.
+		if pdf.Ok() && info != nil {
+			w, h := fitImage(info.Width(), info.Height(), 40, 80)
+			pdf.ImageOptions("poster", margin, margin, w, h, false, opts, 0, "")
+			textX = margin + w + 6
+		} else {
+			pdf.ClearError()
+		}
+	}
+
+	pdf.SetXY(textX, margin)
+	pdf.SetFont("go", "B", 18)
+	pdf.MultiCell(pageWidth-textX-margin, 8, t.Movie, "", "L", false)
+
+	pdf.SetFont("go", "", 11)
+	for _, line := range []string{
+		t.StartTime.Format("02.01.2006 15:04"),
+		t.Room,
+		fmt.Sprintf("Row %d, seat %d", t.Row, t.Seat),
+		fmt.Sprintf("%.2f %s", t.Price, t.Currency),
+	} {
+		pdf.SetX(textX)
+		pdf.CellFormat(pageWidth-textX-margin, 7, line, "", 1, "L", false, 0, "")
+	}
+
+	const qrSize = 70.0
+	qrY := 100.0
+	opts := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("qr", opts, bytes.NewReader(qr))
+	pdf.ImageOptions("qr", (pageWidth-qrSize)/2, qrY, qrSize, qrSize, false, opts, 0, "")
+
+	pdf.SetXY(margin, qrY+qrSize+4)
+	pdf.SetFont("go", "", 8)
+	pdf.CellFormat(pageWidth-2*margin, 5, t.ID, "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// fitImage scales an image down to fit into a maxW by maxH box, keeping its
+// aspect ratio.
+func fitImage(w, h, maxW, maxH float64) (float64, float64) {
+	scale := min(maxW/w, maxH/h)
+	return w * scale, h * scale
+}
+
+func posterType(image []byte) string {
+	if len(image) == 0 {
+		return ""
+	}
+
+	switch http.DetectContentType(image) {
+	case "image/jpeg":
+		return "JPG"
+	case "image/png":
+		return "PNG"
+	case "image/gif":
+		return "GIF"
+	default:
+		return ""
+	}
+}
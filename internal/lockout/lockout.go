@@ -0,0 +1,110 @@
+// Package lockout tracks failed sign-in attempts and slows down or locks out
+// whoever keeps failing.
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// Attempt is the failure history of a key, e.g. an account or an IP address.
+type Attempt struct {
+	Failures      int
+	LockedUntil   time.Time
+	LastFailureAt time.Time
+}
+
+// RetryAfter returns how long the key stays locked at the given time.
+func (a Attempt) RetryAfter(now time.Time) time.Duration {
+	if a.LockedUntil.After(now) {
+		return a.LockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// Backend stores attempts. Update must apply fn atomically, so that
+// concurrent failures of the same key are all counted.
+type Backend interface {
+	Get(ctx context.Context, key string) (Attempt, error)
+	Update(ctx context.Context, key string, fn func(*Attempt)) (Attempt, error)
+	Delete(ctx context.Context, key string) error
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Policy decides how failures are punished. The first FreeAttempts failures
+// are free, every further one locks the key for a delay that starts at
+// BaseDelay and doubles each time, and reaching MaxAttempts locks it for
+// LockoutDuration. Failures are forgotten after Window without any.
+type Policy struct {
+	FreeAttempts    int
+	MaxAttempts     int
+	BaseDelay       time.Duration
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+func (p Policy) fail(a *Attempt, now time.Time) {
+	if now.Sub(a.LastFailureAt) > p.Window {
+		*a = Attempt{}
+	}
+
+	a.Failures++
+	a.LastFailureAt = now
+
+	switch {
+	case a.Failures >= p.MaxAttempts:
+		a.LockedUntil = now.Add(p.LockoutDuration)
+	case a.Failures > p.FreeAttempts:
+		delay := p.BaseDelay << (a.Failures - p.FreeAttempts - 1)
+		if delay <= 0 || delay > p.LockoutDuration {
+			delay = p.LockoutDuration
+		}
+		a.LockedUntil = now.Add(delay)
+	}
+}
+
+// Limiter applies a policy to keys of one kind.
+type Limiter struct {
+	backend Backend
+	policy  Policy
+	prefix  string
+}
+
+// NewLimiter returns a limiter for keys of one kind. The prefix keeps keys of
+// different kinds apart when they share a backend.
+func NewLimiter(backend Backend, prefix string, policy Policy) *Limiter {
+	return &Limiter{backend: backend, policy: policy, prefix: prefix}
+}
+
+// RetryAfter returns how long the key stays locked, or zero if it may try
+// now.
+func (l *Limiter) RetryAfter(ctx context.Context, key string) (time.Duration, error) {
+	a, err := l.backend.Get(ctx, l.prefix+key)
+	if err != nil {
+		return 0, err
+	}
+	return a.RetryAfter(time.Now()), nil
+}
+
+// Fail records a failed attempt of the key. lockedOut reports whether this
+// failure started a lockout.
+func (l *Limiter) Fail(ctx context.Context, key string) (a Attempt, lockedOut bool, err error) {
+	a, err = l.backend.Update(ctx, l.prefix+key, func(a *Attempt) {
+		l.policy.fail(a, time.Now())
+	})
+	if err != nil {
+		return Attempt{}, false, err
+	}
+
+	return a, a.Failures == l.policy.MaxAttempts, nil
+}
+
+// Reset forgets the failures of the key, e.g. after a successful sign-in.
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.backend.Delete(ctx, l.prefix+key)
+}
@@ -0,0 +1,59 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBackend keeps attempts in process. It suits a single API instance;
+// with several instances every one of them counts failures on its own.
+type MemoryBackend struct {
+	mu       sync.Mutex
+	attempts map[string]Attempt
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{attempts: map[string]Attempt{}}
+}
+
+func (b *MemoryBackend) Get(ctx context.Context, key string) (Attempt, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.attempts[key], nil
+}
+
+func (b *MemoryBackend) Update(ctx context.Context, key string, fn func(*Attempt)) (Attempt, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	a := b.attempts[key]
+	fn(&a)
+	b.attempts[key] = a
+
+	return a, nil
+}
+
+func (b *MemoryBackend) Delete(ctx context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.attempts, key)
+	return nil
+}
+
+func (b *MemoryBackend) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var deleted int64
+	for key, a := range b.attempts {
+		if a.LastFailureAt.Before(before) && !a.LockedUntil.After(time.Now()) {
+			delete(b.attempts, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
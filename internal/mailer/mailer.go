@@ -3,14 +3,25 @@ package mailer
 import "embed"
 
 const (
-	FromName            = "Tikceto"
-	maxRetries          = 3
-	UserWelcomeTemplate = "user_invitation.tmpl"
+	FromName                    = "Tikceto"
+	UserWelcomeTemplate         = "user_invitation.tmpl"
+	TicketConfirmationTemplate  = "ticket_confirmation.tmpl"
+	PasswordResetTemplate       = "password_reset.tmpl"
+	AccountLockedTemplate       = "account_locked.tmpl"
+	AccountDeletionTemplate     = "account_deletion.tmpl"
+	SessionCancellationTemplate = "session_cancellation.tmpl"
 )
 
 //go:embed "templates"
 var FS embed.FS
 
+// Attachment is a file sent along with an email.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
 type Client interface {
-	Send(templateFile string, username, email string, data any, isSandbox bool) error
+	Send(templateFile string, username, email string, data any, isSandbox bool, attachments ...Attachment) error
 }
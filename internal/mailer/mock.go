@@ -25,7 +25,7 @@ func NewMockMailer(apiKey, fromEmail string) (*MockMailer, error) {
 }
 
 // Send simulates sending an email by printing the email details.
-func (m *MockMailer) Send(templateFile string, username, email string, data any, isSandbox bool) error {
+func (m *MockMailer) Send(templateFile string, username, email string, data any, isSandbox bool, attachments ...Attachment) error {
 	if templateFile == "" || username == "" || email == "" {
 		return errors.New("templateFile, username, and email are required")
 	}
@@ -36,6 +36,9 @@ func (m *MockMailer) Send(templateFile string, username, email string, data any,
 	fmt.Printf("Template: %s\n", templateFile)
 	fmt.Printf("Username: %s\n", username)
 	fmt.Printf("Data: %v\n", data)
+	for _, a := range attachments {
+		fmt.Printf("Attachment: %s (%s, %d bytes)\n", a.Filename, a.ContentType, len(a.Data))
+	}
 
 	// Returning a nil error to simulate a successful send (or you can simulate a failure)
 	return nil
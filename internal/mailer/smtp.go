@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
-	"time"
+	"io"
 
 	"gopkg.in/gomail.v2"
 )
@@ -32,7 +32,7 @@ func NewSmtp(host, username, password, fromEmail string, port int) (*SmtpMailer,
 	}, nil
 }
 
-func (m *SmtpMailer) Send(templateFile string, username, email string, data any, isSandbox bool) error {
+func (m *SmtpMailer) Send(templateFile string, username, email string, data any, isSandbox bool, attachments ...Attachment) error {
 	tmpl, err := template.ParseFS(FS, "templates/"+templateFile)
 	if err != nil {
 		return fmt.Errorf("error parsing template: %w", err)
@@ -57,19 +57,19 @@ func (m *SmtpMailer) Send(templateFile string, username, email string, data any,
 
 	msg.SetBody("text/html", body.String())
 
-	d := gomail.NewDialer(m.host, m.port, m.username, m.password)
-
-	var retryErr error
-	for i := 0; i < maxRetries; i++ {
-		err := d.DialAndSend(msg)
-		if err != nil {
-			retryErr = err
-			time.Sleep(time.Duration(i+1) * time.Second)
-			continue
-		}
-
-		return nil
+	for _, a := range attachments {
+		data := a.Data
+		msg.Attach(
+			a.Filename,
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(data)
+				return err
+			}),
+			gomail.SetHeader(map[string][]string{"Content-Type": {a.ContentType}}),
+		)
 	}
 
-	return fmt.Errorf("failed to send email after %d attempts, error: %s", maxRetries, retryErr)
+	d := gomail.NewDialer(m.host, m.port, m.username, m.password)
+
+	return d.DialAndSend(msg)
 }
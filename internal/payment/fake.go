@@ -0,0 +1,327 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// FakePaymentService is an in-memory payment provider for local development.
+// It serves its own checkout page and sends signed callbacks to the server
+// URL the same way a real provider would.
+type FakePaymentService struct {
+	secret      []byte
+	frontendURL string
+	serverURL   string
+	checkoutURL string
+	client      *http.Client
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+}
+
+type fakePayment struct {
+	PaymentRequest
+	Status        string
+	TransactionID string
+	Refunded      float64
+}
+
+func NewFakePaymentService(secret, frontendURL, serverURL, checkoutURL string) (*FakePaymentService, error) {
+	if serverURL == "" || checkoutURL == "" {
+		return nil, fmt.Errorf("server and checkout URLs required")
+	}
+
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &FakePaymentService{
+		secret:      key,
+		frontendURL: frontendURL,
+		serverURL:   serverURL,
+		checkoutURL: strings.TrimSuffix(checkoutURL, "/"),
+		client:      &http.Client{Timeout: 10 * time.Second},
+		payments:    make(map[string]*fakePayment),
+	}, nil
+}
+
+func (s *FakePaymentService) Name() string {
+	return ProviderFake
+}
+
+func (s *FakePaymentService) CreatePayment(payment PaymentRequest) (*PaymentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.payments[payment.OrderId]; ok {
+		return nil, fmt.Errorf("fake provider: order %s already exists", payment.OrderId)
+	}
+
+	s.payments[payment.OrderId] = &fakePayment{
+		PaymentRequest: payment,
+		Status:         "pending",
+	}
+
+	return &PaymentResponse{
+		OrderId: payment.OrderId,
+		Status:  "pending",
+		Url:     s.checkoutURL + "/" + url.PathEscape(payment.OrderId),
+	}, nil
+}
+
+func (s *FakePaymentService) Refund(refund RefundRequest) (*RefundResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payments[refund.OrderId]
+	if !ok {
+		return nil, fmt.Errorf("fake provider: order %s not found", refund.OrderId)
+	}
+
+	if p.Status != "success" && p.Status != "reversed" {
+		return nil, fmt.Errorf("fake provider: order %s is %s", refund.OrderId, p.Status)
+	}
+
+	if p.Refunded+refund.Amount > p.Amount {
+		return nil, fmt.Errorf("fake provider: refund exceeds the paid amount")
+	}
+
+	p.Refunded += refund.Amount
+	if p.Refunded == p.Amount {
+		p.Status = "reversed"
+	}
+
+	return &RefundResponse{
+		OrderId: refund.OrderId,
+		Amount:  refund.Amount,
+		Status:  "reversed",
+	}, nil
+}
+
+func (s *FakePaymentService) Status(orderID string) (*Callback, error) {
+	s.mu.Lock()
+	p, ok := s.payments[orderID]
+	var callback fakeCallback
+	if ok {
+		callback = p.callback()
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("fake provider: order %s not found", orderID)
+	}
+
+	payload, err := json.Marshal(callback)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Callback{
+		OrderID:        callback.OrderID,
+		TransactionID:  callback.TransactionID,
+		ProviderStatus: callback.Status,
+		Status:         fakeStatus(callback.Status),
+		Amount:         callback.Amount,
+		Currency:       callback.Currency,
+		Payload:        string(payload),
+		SignatureValid: true,
+	}, nil
+}
+
+func (p *fakePayment) callback() fakeCallback {
+	return fakeCallback{
+		OrderID:       p.OrderId,
+		Status:        p.Status,
+		TransactionID: p.TransactionID,
+		Amount:        p.Amount,
+		Currency:      p.Currency,
+	}
+}
+
+// fakeCallback is the payload of a callback sent by the fake provider.
+type fakeCallback struct {
+	OrderID       string  `json:"order_id"`
+	Status        string  `json:"status"`
+	TransactionID string  `json:"transaction_id"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+}
+
+func (s *FakePaymentService) ParseCallback(r *http.Request) (*Callback, error) {
+	data := r.FormValue("data")
+	signature := r.FormValue("signature")
+
+	callback := &Callback{
+		Payload:        data,
+		SignatureValid: hmac.Equal([]byte(signature), []byte(s.sign(data))),
+	}
+
+	if !callback.SignatureValid {
+		return callback, ErrInvalidSignature
+	}
+
+	decodedData, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return callback, err
+	}
+	callback.Payload = string(decodedData)
+
+	var payload fakeCallback
+	if err := json.Unmarshal(decodedData, &payload); err != nil {
+		return callback, err
+	}
+
+	callback.OrderID = payload.OrderID
+	callback.TransactionID = payload.TransactionID
+	callback.ProviderStatus = payload.Status
+	callback.Status = fakeStatus(payload.Status)
+	callback.Amount = payload.Amount
+	callback.Currency = payload.Currency
+
+	return callback, nil
+}
+
+func fakeStatus(status string) string {
+	switch status {
+	case "success":
+		return StatusConfirmed
+	case "failure":
+		return StatusFailed
+	case "reversed":
+		return StatusRefunded
+	default:
+		return StatusPending
+	}
+}
+
+func (s *FakePaymentService) sign(data string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Handler serves the checkout page. It is mounted at the checkout URL.
+func (s *FakePaymentService) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/{orderID}", s.checkoutPageHandler)
+	r.Post("/{orderID}", s.checkoutHandler)
+	return r
+}
+
+var checkoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Fake checkout</title></head>
+<body>
+	<h1>Fake checkout</h1>
+	<p>{{.Description}}</p>
+	<p>Order: {{.OrderId}}</p>
+	<p>Amount: {{printf "%.2f" .Amount}} {{.Currency}}</p>
+	{{if eq .Status "pending"}}
+	<form method="post">
+		<button name="result" value="success">Pay</button>
+		<button name="result" value="failure">Decline</button>
+	</form>
+	{{else}}
+	<p>Status: {{.Status}}</p>
+	{{end}}
+</body>
+</html>
+`))
+
+func (s *FakePaymentService) checkoutPageHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	p, ok := s.payments[chi.URLParam(r, "orderID")]
+	var page fakePayment
+	if ok {
+		page = *p
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := checkoutPage.Execute(w, page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *FakePaymentService) checkoutHandler(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderID")
+
+	result := r.FormValue("result")
+	if result != "success" && result != "failure" {
+		http.Error(w, "result must be success or failure", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	p, ok := s.payments[orderID]
+	if !ok {
+		s.mu.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+
+	if p.Status != "pending" {
+		s.mu.Unlock()
+		http.Error(w, "the order is already "+p.Status, http.StatusConflict)
+		return
+	}
+
+	p.Status = result
+	p.TransactionID = strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	callback := p.callback()
+	s.mu.Unlock()
+
+	if err := s.sendCallback(callback); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	http.Redirect(w, r, s.frontendURL+orderID, http.StatusSeeOther)
+}
+
+func (s *FakePaymentService) sendCallback(callback fakeCallback) error {
+	payload, err := json.Marshal(callback)
+	if err != nil {
+		return err
+	}
+
+	data := base64.StdEncoding.EncodeToString(payload)
+
+	resp, err := s.client.PostForm(s.serverURL, url.Values{
+		"data":      {data},
+		"signature": {s.sign(data)},
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("callback rejected with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
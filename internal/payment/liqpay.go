@@ -3,37 +3,54 @@ package payment
 import (
 	"crypto/sha1"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"github.com/liqpay/go-sdk"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
+const liqpayAPIURL = "https://www.liqpay.ua/api/"
+
 type LiqPayPaymentService struct {
 	publicKey   string
 	privateKey  string
 	frontendURL string
 	serverURL   string
+	apiURL      string
+	client      *http.Client
 }
 
-func NewLiqPayPaymentService(publicKey, privateKey, frontendURL, serverURL string) (*LiqPayPaymentService, error) {
+// NewLiqPayPaymentService creates a LiqPay client. An empty apiURL selects
+// the public LiqPay API; tests and local stand-ins can point it elsewhere.
+func NewLiqPayPaymentService(publicKey, privateKey, frontendURL, serverURL, apiURL string) (*LiqPayPaymentService, error) {
 	if publicKey == "" || privateKey == "" || frontendURL == "" {
 		return nil, fmt.Errorf("API keys required")
 	}
+
+	if apiURL == "" {
+		apiURL = liqpayAPIURL
+	}
+
 	return &LiqPayPaymentService{
 		publicKey:   publicKey,
 		privateKey:  privateKey,
 		frontendURL: frontendURL,
 		serverURL:   serverURL,
+		apiURL:      strings.TrimSuffix(apiURL, "/") + "/",
+		client:      &http.Client{Timeout: 30 * time.Second},
 	}, nil
 }
 
-func (s *LiqPayPaymentService) CreatePayment(payment PaymentRequest) (*PaymentResponse, error) {
-	c := liqpay.New(s.publicKey, s.privateKey, nil)
+func (s *LiqPayPaymentService) Name() string {
+	return ProviderLiqPay
+}
 
+func (s *LiqPayPaymentService) CreatePayment(payment PaymentRequest) (*PaymentResponse, error) {
 	request := map[string]interface{}{
 		"action":         "payment_prepare",
 		"action_payment": "pay",
-		"version":        3,
-		"public_key":     s.publicKey,
 		"amount":         payment.Amount,
 		"currency":       payment.Currency,
 		"description":    payment.Description,
@@ -43,24 +60,111 @@ func (s *LiqPayPaymentService) CreatePayment(payment PaymentRequest) (*PaymentRe
 		"server_url":     s.serverURL,
 	}
 
-	resp, err := c.Send("request", request)
+	resp, _, err := s.send(request)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp["result"] != "ok" {
-		return nil, fmt.Errorf("liqpay API error: %s", resp["err"])
-	}
-
-	urlCheckout, _ := resp["url_checkout"].(string)
-
 	return &PaymentResponse{
 		OrderId: payment.OrderId,
 		Status:  "pending",
-		Url:     urlCheckout,
+		Url:     resp.URLCheckout,
+	}, nil
+}
+
+func (s *LiqPayPaymentService) Refund(refund RefundRequest) (*RefundResponse, error) {
+	request := map[string]interface{}{
+		"action":   "refund",
+		"order_id": refund.OrderId,
+		"amount":   refund.Amount,
+	}
+
+	resp, _, err := s.send(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RefundResponse{
+		OrderId: refund.OrderId,
+		Amount:  refund.Amount,
+		Status:  resp.Status,
 	}, nil
 }
 
+func (s *LiqPayPaymentService) Status(orderID string) (*Callback, error) {
+	request := map[string]interface{}{
+		"action":   "status",
+		"order_id": orderID,
+	}
+
+	resp, body, err := s.send(request)
+	if err != nil {
+		return nil, err
+	}
+
+	callback := resp.liqpayCallback.callback()
+	callback.Payload = string(body)
+	callback.SignatureValid = true
+
+	return callback, nil
+}
+
+// liqpayResponse is the part of a LiqPay API response the API acts upon.
+type liqpayResponse struct {
+	liqpayCallback
+	Result         string `json:"result"`
+	ErrCode        string `json:"err_code"`
+	ErrDescription string `json:"err_description"`
+	URLCheckout    string `json:"url_checkout"`
+}
+
+// send signs the request and posts it to the LiqPay API. It fails unless
+// LiqPay reports the request as successful.
+func (s *LiqPayPaymentService) send(request map[string]interface{}) (*liqpayResponse, []byte, error) {
+	request["version"] = 3
+	request["public_key"] = s.publicKey
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := base64.StdEncoding.EncodeToString(payload)
+
+	resp, err := s.client.PostForm(s.apiURL+"request", url.Values{
+		"data":      {data},
+		"signature": {s.GenerateSignature(data)},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("liqpay API error: unexpected status %d", resp.StatusCode)
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, nil, err
+	}
+
+	var result liqpayResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, nil, err
+	}
+
+	if result.Result != "ok" {
+		description := result.ErrDescription
+		if description == "" {
+			description = result.ErrCode
+		}
+		return nil, nil, fmt.Errorf("liqpay API error: %s", description)
+	}
+
+	return &result, body, nil
+}
+
 func (s *LiqPayPaymentService) GenerateSignature(data string) string {
 	signatureSource := s.privateKey + data + s.privateKey
 
@@ -71,3 +175,70 @@ func (s *LiqPayPaymentService) GenerateSignature(data string) string {
 	signature := base64.StdEncoding.EncodeToString(hashBytes)
 	return signature
 }
+
+// liqpayCallback is the part of a LiqPay callback the API acts upon.
+type liqpayCallback struct {
+	OrderID       string      `json:"order_id"`
+	Status        string      `json:"status"`
+	TransactionID json.Number `json:"transaction_id"`
+	Amount        float64     `json:"amount"`
+	Currency      string      `json:"currency"`
+}
+
+func (c liqpayCallback) callback() *Callback {
+	return &Callback{
+		OrderID:        c.OrderID,
+		TransactionID:  c.TransactionID.String(),
+		ProviderStatus: c.Status,
+		Status:         liqpayStatus(c.Status),
+		Amount:         c.Amount,
+		Currency:       c.Currency,
+	}
+}
+
+func (s *LiqPayPaymentService) ParseCallback(r *http.Request) (*Callback, error) {
+	data := r.FormValue("data")
+	signature := r.FormValue("signature")
+
+	callback := &Callback{
+		Payload:        data,
+		SignatureValid: signature == s.GenerateSignature(data),
+	}
+
+	if !callback.SignatureValid {
+		return callback, ErrInvalidSignature
+	}
+
+	decodedData, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return callback, err
+	}
+	callback.Payload = string(decodedData)
+
+	var payload liqpayCallback
+	if err := json.Unmarshal(decodedData, &payload); err != nil {
+		return callback, err
+	}
+
+	parsed := payload.callback()
+	parsed.Payload = callback.Payload
+	parsed.SignatureValid = true
+
+	return parsed, nil
+}
+
+// liqpayStatus maps a LiqPay payment status onto the payment state machine.
+// Intermediate statuses such as "processing" or "wait_accept" keep the
+// payment pending.
+func liqpayStatus(status string) string {
+	switch status {
+	case "success", "sandbox":
+		return StatusConfirmed
+	case "failure", "error":
+		return StatusFailed
+	case "reversed":
+		return StatusRefunded
+	default:
+		return StatusPending
+	}
+}
@@ -1,5 +1,27 @@
 package payment
 
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	ProviderLiqPay = "liqpay"
+	ProviderFake   = "fake"
+)
+
+// Payment statuses a provider callback is mapped onto. They mirror the
+// statuses of the payments ledger.
+const (
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+	StatusFailed    = "failed"
+	StatusRefunded  = "refunded"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
 type PaymentRequest struct {
 	Amount      float64 `json:"amount"`
 	Currency    string  `json:"currency"`
@@ -13,7 +35,72 @@ type PaymentResponse struct {
 	Url     string `json:"url"`
 }
 
+type RefundRequest struct {
+	OrderId string  `json:"order_id"`
+	Amount  float64 `json:"amount"`
+}
+
+type RefundResponse struct {
+	OrderId string  `json:"order_id"`
+	Amount  float64 `json:"amount"`
+	Status  string  `json:"status"`
+}
+
+// Callback is a provider notification about a payment, already verified and
+// mapped onto the payment statuses.
+type Callback struct {
+	OrderID        string
+	TransactionID  string
+	ProviderStatus string
+	Status         string
+	Amount         float64
+	Currency       string
+	Payload        string
+	SignatureValid bool
+}
+
 type Client interface {
+	Name() string
 	CreatePayment(payment PaymentRequest) (*PaymentResponse, error)
-	GenerateSignature(data string) string
+	Refund(refund RefundRequest) (*RefundResponse, error)
+	// Status asks the provider for the current state of a payment, for when
+	// its callbacks do not arrive.
+	Status(orderID string) (*Callback, error)
+	// ParseCallback reads a callback sent by the provider to the server URL.
+	// The returned callback carries the raw payload even when parsing fails,
+	// so that rejected callbacks can be recorded too.
+	ParseCallback(r *http.Request) (*Callback, error)
+}
+
+// Checkout is implemented by providers that serve their own checkout pages
+// from the API.
+type Checkout interface {
+	Handler() http.Handler
+}
+
+type Config struct {
+	PublicKey   string
+	PrivateKey  string
+	FrontendURL string
+	ServerURL   string
+	CheckoutURL string
+	APIURL      string
+}
+
+var providers = map[string]func(cfg Config) (Client, error){
+	ProviderLiqPay: func(cfg Config) (Client, error) {
+		return NewLiqPayPaymentService(cfg.PublicKey, cfg.PrivateKey, cfg.FrontendURL, cfg.ServerURL, cfg.APIURL)
+	},
+	ProviderFake: func(cfg Config) (Client, error) {
+		return NewFakePaymentService(cfg.PrivateKey, cfg.FrontendURL, cfg.ServerURL, cfg.CheckoutURL)
+	},
+}
+
+// New creates the payment provider registered under name.
+func New(name string, cfg Config) (Client, error) {
+	newProvider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+	return newProvider(cfg)
 }
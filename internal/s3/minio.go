@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"io"
 	"path/filepath"
 )
 
@@ -64,6 +65,16 @@ func (m *minioClient) GetOne(objectID string) (string, error) {
 	return fileURL, nil
 }
 
+func (m *minioClient) Download(ctx context.Context, objectID string) ([]byte, error) {
+	object, err := m.client.GetObject(ctx, m.bucketName, objectID, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	return io.ReadAll(object)
+}
+
 func (m *minioClient) DeleteOne(ctx context.Context, objectID string) error {
 	err := m.client.RemoveObject(ctx, m.bucketName, objectID, minio.RemoveObjectOptions{})
 	if err != nil {
@@ -10,5 +10,6 @@ type FileDataType struct {
 type Client interface {
 	CreateOne(ctx context.Context, file FileDataType) (string, error)
 	GetOne(objectID string) (string, error)
+	Download(ctx context.Context, objectID string) ([]byte, error)
 	DeleteOne(ctx context.Context, objectID string) error
 }
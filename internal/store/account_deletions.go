@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// CreateAccountDeletion stores the hashed token that confirms the deletion of
+// the account of a user, valid for exp.
+func (s *UsersStore) CreateAccountDeletion(ctx context.Context, userID int64, token string, exp time.Duration) error {
+	query := `INSERT INTO account_deletions (token, user_id, expiry) VALUES ($1, $2, $3)`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, token, userID, time.Now().Add(exp))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteAccount deletes the account owning the plain confirmation token and
+// returns what it was. Orders, tickets and payments of the account are kept
+// but no longer point to it; nothing identifying the person is left behind.
+func (s *UsersStore) DeleteAccount(ctx context.Context, token string) (*User, error) {
+	user := &User{}
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.getUserFromAccountDeletion(ctx, tx, token, user); err != nil {
+			return err
+		}
+
+		if err := s.delete(ctx, tx, user.ID); err != nil {
+			return err
+		}
+
+		return s.deleteUserInvitations(ctx, tx, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *UsersStore) getUserFromAccountDeletion(ctx context.Context, tx *sql.Tx, token string, user *User) error {
+	query := `
+		SELECT u.id, u.username, u.email
+		FROM account_deletions ad
+		JOIN users u ON u.id = ad.user_id
+		WHERE ad.token = $1 AND ad.expiry > $2
+		FOR UPDATE
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	hash := sha256.Sum256([]byte(token))
+	hashToken := hex.EncodeToString(hash[:])
+
+	err := tx.QueryRowContext(ctx, query, hashToken, time.Now()).Scan(&user.ID, &user.Username, &user.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type HoldStore struct {
+	db *sql.DB
+}
+
+// ReleaseExpired drops every hold past its expiry and marks the pending
+// tickets behind them, together with their orders, as expired, freeing their
+// seats. It returns the number of released tickets.
+func (s *HoldStore) ReleaseExpired(ctx context.Context) (int64, error) {
+	query := `
+		WITH expired AS (
+			DELETE FROM seat_holds WHERE expires_at <= NOW() RETURNING ticket_id
+		), released AS (
+			UPDATE tickets SET status = $1
+			WHERE id IN (SELECT ticket_id FROM expired) AND status = $2
+			RETURNING order_id
+		), expired_orders AS (
+			UPDATE orders SET status = $3
+			WHERE id IN (SELECT order_id FROM released) AND status = $4
+		)
+		SELECT COUNT(*) FROM released
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var released int64
+	err := s.db.QueryRowContext(
+		ctx, query,
+		TicketStatusExpired, TicketStatusPending, OrderStatusExpired, OrderStatusPending,
+	).Scan(&released)
+	if err != nil {
+		return 0, err
+	}
+
+	return released, nil
+}
+
+func createHold(ctx context.Context, tx *sql.Tx, ticketID string, exp time.Duration) error {
+	query := `INSERT INTO seat_holds (ticket_id, expires_at) VALUES ($1, $2)`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, ticketID, time.Now().Add(exp))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/k5sha/Tikceto/internal/lockout"
+)
+
+// LoginAttemptStore is the Postgres lockout backend, shared by every API
+// instance.
+type LoginAttemptStore struct {
+	db *sql.DB
+}
+
+func (s *LoginAttemptStore) Get(ctx context.Context, key string) (lockout.Attempt, error) {
+	query := `SELECT failures, locked_until, last_failure_at FROM login_attempts WHERE key = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return scanLoginAttempt(s.db.QueryRowContext(ctx, query, key))
+}
+
+// Update applies fn to the attempt of the key while holding a row lock on it.
+func (s *LoginAttemptStore) Update(ctx context.Context, key string, fn func(*lockout.Attempt)) (lockout.Attempt, error) {
+	var attempt lockout.Attempt
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		_, err := tx.ExecContext(ctx, `INSERT INTO login_attempts (key) VALUES ($1) ON CONFLICT (key) DO NOTHING`, key)
+		if err != nil {
+			return err
+		}
+
+		attempt, err = scanLoginAttempt(tx.QueryRowContext(
+			ctx,
+			`SELECT failures, locked_until, last_failure_at FROM login_attempts WHERE key = $1 FOR UPDATE`,
+			key,
+		))
+		if err != nil {
+			return err
+		}
+
+		fn(&attempt)
+
+		var lockedUntil *time.Time
+		if !attempt.LockedUntil.IsZero() {
+			lockedUntil = &attempt.LockedUntil
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE login_attempts SET failures = $2, locked_until = $3, last_failure_at = $4 WHERE key = $1`,
+			key, attempt.Failures, lockedUntil, attempt.LastFailureAt,
+		)
+		return err
+	})
+	if err != nil {
+		return lockout.Attempt{}, err
+	}
+
+	return attempt, nil
+}
+
+func (s *LoginAttemptStore) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE key = $1`, key)
+	return err
+}
+
+// DeleteStale drops attempts whose last failure happened before the given
+// time and that are no longer locked.
+func (s *LoginAttemptStore) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM login_attempts
+		WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until <= NOW())
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func scanLoginAttempt(row *sql.Row) (lockout.Attempt, error) {
+	var (
+		attempt     lockout.Attempt
+		lockedUntil sql.NullTime
+	)
+
+	err := row.Scan(&attempt.Failures, &lockedUntil, &attempt.LastFailureAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return lockout.Attempt{}, nil
+		}
+		return lockout.Attempt{}, err
+	}
+
+	attempt.LockedUntil = lockedUntil.Time
+
+	return attempt, nil
+}
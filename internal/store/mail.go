@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const (
+	MailStatusPending = "pending"
+	MailStatusSent    = "sent"
+	MailStatusDead    = "dead"
+)
+
+type MailAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// Mail is an email waiting in the outbox to be delivered. Data holds the
+// template variables as JSON.
+type Mail struct {
+	ID            int64            `json:"id"`
+	Template      string           `json:"template"`
+	Username      string           `json:"username"`
+	Email         string           `json:"email"`
+	Data          json.RawMessage  `json:"data"`
+	Attachments   []MailAttachment `json:"attachments,omitempty"`
+	IsSandbox     bool             `json:"is_sandbox"`
+	Status        string           `json:"status"`
+	Attempts      int              `json:"attempts"`
+	LastError     *string          `json:"last_error,omitempty"`
+	NextAttemptAt string           `json:"next_attempt_at"`
+	SentAt        *string          `json:"sent_at,omitempty"`
+	CreatedAt     string           `json:"created_at"`
+}
+
+type MailStore struct {
+	db *sql.DB
+}
+
+func (s *MailStore) Enqueue(ctx context.Context, mail *Mail) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return enqueueMail(ctx, tx, mail)
+	})
+}
+
+// enqueueMail stores the email in the outbox as part of tx, so that it is
+// only sent if the changes it tells about are committed.
+func enqueueMail(ctx context.Context, tx *sql.Tx, mail *Mail) error {
+	query := `
+		INSERT INTO mail_outbox (template, username, email, data, attachments, is_sandbox)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, attempts, next_attempt_at, created_at
+	`
+
+	attachments, err := json.Marshal(mail.Attachments)
+	if err != nil {
+		return err
+	}
+
+	if mail.Attachments == nil {
+		attachments = []byte("[]")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return tx.QueryRowContext(
+		ctx, query,
+		mail.Template, mail.Username, mail.Email, []byte(mail.Data), attachments, mail.IsSandbox,
+	).Scan(&mail.ID, &mail.Status, &mail.Attempts, &mail.NextAttemptAt, &mail.CreatedAt)
+}
+
+// ClaimDue takes up to limit pending emails that are due for delivery and
+// counts a delivery attempt for each. Claimed emails are not due again until
+// lease passes, so concurrent workers do not deliver them twice and emails of
+// a crashed worker are retried.
+func (s *MailStore) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Mail, error) {
+	query := `
+		UPDATE mail_outbox
+		SET attempts = attempts + 1, next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM mail_outbox
+			WHERE status = $2 AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, template, username, email, data, attachments, is_sandbox, status, attempts,
+		          last_error, next_attempt_at, sent_at, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, time.Now().Add(lease), MailStatusPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mails []Mail
+	for rows.Next() {
+		var mail Mail
+		var data, attachments []byte
+
+		err := rows.Scan(
+			&mail.ID, &mail.Template, &mail.Username, &mail.Email, &data, &attachments, &mail.IsSandbox,
+			&mail.Status, &mail.Attempts, &mail.LastError, &mail.NextAttemptAt, &mail.SentAt, &mail.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		mail.Data = data
+		if err := json.Unmarshal(attachments, &mail.Attachments); err != nil {
+			return nil, err
+		}
+
+		mails = append(mails, mail)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return mails, nil
+}
+
+// MarkSent records a delivered email. Its attachments are dropped, as they are
+// not needed once sent and would otherwise pile up in the outbox.
+func (s *MailStore) MarkSent(ctx context.Context, id int64) error {
+	query := `UPDATE mail_outbox SET status = $1, sent_at = NOW(), last_error = NULL, attachments = '[]' WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, MailStatusSent, id)
+	return err
+}
+
+// MarkFailed records a failed delivery attempt. The email is retried at
+// retryAt, or dead-lettered if retryAt is nil.
+func (s *MailStore) MarkFailed(ctx context.Context, id int64, reason string, retryAt *time.Time) error {
+	query := `UPDATE mail_outbox SET status = $1, last_error = $2, next_attempt_at = COALESCE($3, next_attempt_at) WHERE id = $4`
+
+	status := MailStatusPending
+	if retryAt == nil {
+		status = MailStatusDead
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, status, reason, retryAt, id)
+	return err
+}
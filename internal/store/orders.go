@@ -0,0 +1,224 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusConfirmed = "confirmed"
+	OrderStatusFailed    = "failed"
+	OrderStatusExpired   = "expired"
+	OrderStatusRefunded  = "refunded"
+)
+
+type Order struct {
+	ID        string   `json:"id"`
+	UserID    *int64   `json:"user_id"`
+	Amount    float64  `json:"amount"`
+	Currency  string   `json:"currency"`
+	Status    string   `json:"status"`
+	CreatedAt string   `json:"created_at"`
+	Tickets   []Ticket `json:"tickets"`
+}
+
+// OwnedBy reports whether the order was placed by the user. Orders of deleted
+// accounts belong to no one.
+func (o *Order) OwnedBy(userID int64) bool {
+	return o.UserID != nil && *o.UserID == userID
+}
+
+type OrderStore struct {
+	db *sql.DB
+}
+
+func (s *OrderStore) GetByID(ctx context.Context, id string) (*Order, error) {
+	query := `
+		SELECT id, user_id, amount, currency, status, created_at
+		FROM orders
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	order := &Order{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&order.ID, &order.UserID, &order.Amount, &order.Currency, &order.Status, &order.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	tickets, err := s.getTickets(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	order.Tickets = tickets
+
+	return order, nil
+}
+
+func (s *OrderStore) getTickets(ctx context.Context, orderID string) ([]Ticket, error) {
+	query := `
+		SELECT
+			t.id, t.order_id, t.session_id, t.seat_id, t.user_id, t.price, t.status, t.created_at,
+			se.id, se.room_id, se.row, se.seat_number, se.type
+		FROM tickets t
+		JOIN seats se ON t.seat_id = se.id
+		WHERE t.order_id = $1
+		ORDER BY t.session_id, se.row, se.seat_number
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []Ticket
+	for rows.Next() {
+		var ticket Ticket
+		err := rows.Scan(
+			&ticket.ID, &ticket.OrderID, &ticket.SessionID, &ticket.SeatID, &ticket.UserID, &ticket.Price, &ticket.Status, &ticket.CreatedAt,
+			&ticket.Seat.ID, &ticket.Seat.RoomID, &ticket.Seat.Row, &ticket.Seat.Number, &ticket.Seat.Type,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		tickets = append(tickets, ticket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tickets, nil
+}
+
+// Create stores the order together with its pending tickets in a single
+// transaction and holds every seat until holdExp passes. If any seat is
+// already taken the whole order is rolled back.
+func (s *OrderStore) Create(ctx context.Context, order *Order, holdExp time.Duration) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.create(ctx, tx, order); err != nil {
+			return err
+		}
+
+		for i := range order.Tickets {
+			ticket := &order.Tickets[i]
+			ticket.OrderID = &order.ID
+			ticket.UserID = order.UserID
+
+			if err := createTicket(ctx, tx, ticket, TicketStatusPending); err != nil {
+				return err
+			}
+
+			if err := createHold(ctx, tx, ticket.ID, holdExp); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *OrderStore) create(ctx context.Context, tx *sql.Tx, order *Order) error {
+	query := `
+		INSERT INTO orders (user_id, amount, currency, status)
+		VALUES ($1, $2, $3, $4) RETURNING id, status, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return tx.QueryRowContext(
+		ctx, query,
+		order.UserID, order.Amount, order.Currency, OrderStatusPending,
+	).Scan(&order.ID, &order.Status, &order.CreatedAt)
+}
+
+// settleOrder moves a pending order and all of its pending tickets to the
+// given statuses and drops their seat holds. It returns ErrInvalidTransition
+// if the order is no longer pending, e.g. because its holds have expired.
+func settleOrder(ctx context.Context, tx *sql.Tx, id, orderStatus, ticketStatus string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE orders SET status = $1 WHERE id = $2 AND status = $3`,
+		orderStatus, id, OrderStatusPending,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrInvalidTransition
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE tickets SET status = $1 WHERE order_id = $2 AND status = $3`,
+		ticketStatus, id, TicketStatusPending,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM seat_holds WHERE ticket_id IN (SELECT id FROM tickets WHERE order_id = $1)`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// refundOrder marks a confirmed order and all of its confirmed tickets as
+// refunded, freeing their seats.
+func refundOrder(ctx context.Context, tx *sql.Tx, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE orders SET status = $1 WHERE id = $2 AND status = $3`,
+		OrderStatusRefunded, id, OrderStatusConfirmed,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE tickets SET status = $1 WHERE order_id = $2 AND status = $3`,
+		TicketStatusRefunded, id, TicketStatusConfirmed,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
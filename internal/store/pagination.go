@@ -66,6 +66,202 @@ func (pq *PaginatedMoviesQuery) Parse(r *http.Request) (PaginatedMoviesQuery, er
 	return *pq, nil
 }
 
+type PaginatedPaymentsQuery struct {
+	Limit  int     `json:"limit" validate:"min=1,max=100"`
+	Offset int     `json:"offset" validate:"min=0"`
+	Sort   string  `json:"sort" validate:"oneof=asc desc"`
+	Status string  `json:"status" validate:"omitempty,max=50"`
+	Since  *string `json:"since"`
+	Until  *string `json:"until"`
+}
+
+type PaginatedPaymentsResponse struct {
+	Data  []Payment `json:"data"`
+	Total int       `json:"total"`
+}
+
+func (pq *PaginatedPaymentsQuery) Parse(r *http.Request) (PaginatedPaymentsQuery, error) {
+	qs := r.URL.Query()
+
+	if limit := qs.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return *pq, err
+		}
+		pq.Limit = l
+	}
+
+	if offset := qs.Get("offset"); offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return *pq, err
+		}
+		pq.Offset = o
+	}
+
+	if sort := qs.Get("sort"); sort != "" {
+		pq.Sort = sort
+	}
+
+	if status := qs.Get("status"); status != "" {
+		pq.Status = status
+	}
+
+	if since := qs.Get("since"); since != "" {
+		if validSince := parseDate(since); validSince != "" {
+			pq.Since = &validSince
+		}
+	}
+
+	if until := qs.Get("until"); until != "" {
+		if validUntil := parseDate(until); validUntil != "" {
+			pq.Until = &validUntil
+		}
+	}
+
+	return *pq, nil
+}
+
+type PaginatedUsersQuery struct {
+	Limit  int    `json:"limit" validate:"min=1,max=100"`
+	Offset int    `json:"offset" validate:"min=0"`
+	Sort   string `json:"sort" validate:"oneof=asc desc"`
+	Search string `json:"search" validate:"max=100"`
+	Role   string `json:"role" validate:"max=100"`
+	Status string `json:"status" validate:"omitempty,oneof=active inactive banned"`
+}
+
+type PaginatedUsersResponse struct {
+	Data  []User `json:"data"`
+	Total int    `json:"total"`
+}
+
+func (pq *PaginatedUsersQuery) Parse(r *http.Request) (PaginatedUsersQuery, error) {
+	qs := r.URL.Query()
+
+	if limit := qs.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return *pq, err
+		}
+		pq.Limit = l
+	}
+
+	if offset := qs.Get("offset"); offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return *pq, err
+		}
+		pq.Offset = o
+	}
+
+	if sort := qs.Get("sort"); sort != "" {
+		pq.Sort = sort
+	}
+
+	if search := qs.Get("search"); search != "" {
+		pq.Search = search
+	}
+
+	if role := qs.Get("role"); role != "" {
+		pq.Role = role
+	}
+
+	if status := qs.Get("status"); status != "" {
+		pq.Status = status
+	}
+
+	return *pq, nil
+}
+
+type PaginatedSessionsQuery struct {
+	Limit    int      `json:"limit" validate:"min=1,max=100"`
+	Offset   int      `json:"offset" validate:"min=0"`
+	From     string   `json:"from" validate:"omitempty,datetime=2006-01-02"`
+	To       string   `json:"to" validate:"omitempty,datetime=2006-01-02"`
+	RoomID   int64    `json:"room_id" validate:"min=0"`
+	MovieID  int64    `json:"movie_id" validate:"min=0"`
+	Format   string   `json:"format" validate:"omitempty,oneof=2D 3D IMAX 4DX"`
+	MinPrice *float64 `json:"min_price" validate:"omitempty,gte=0"`
+	MaxPrice *float64 `json:"max_price" validate:"omitempty,gte=0"`
+
+	// Since and Until bound the start time of the sessions. They are worked
+	// out from From and To in the local time of the cinema.
+	Since time.Time `json:"-"`
+	Until time.Time `json:"-"`
+}
+
+type PaginatedSessionsResponse struct {
+	Data  []TimetableSession `json:"data"`
+	Total int                `json:"total"`
+}
+
+func (pq *PaginatedSessionsQuery) Parse(r *http.Request) (PaginatedSessionsQuery, error) {
+	qs := r.URL.Query()
+
+	if limit := qs.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return *pq, err
+		}
+		pq.Limit = l
+	}
+
+	if offset := qs.Get("offset"); offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return *pq, err
+		}
+		pq.Offset = o
+	}
+
+	if from := qs.Get("from"); from != "" {
+		pq.From = from
+	}
+
+	if to := qs.Get("to"); to != "" {
+		pq.To = to
+	}
+
+	if roomID := qs.Get("room_id"); roomID != "" {
+		id, err := strconv.ParseInt(roomID, 10, 64)
+		if err != nil {
+			return *pq, err
+		}
+		pq.RoomID = id
+	}
+
+	if movieID := qs.Get("movie_id"); movieID != "" {
+		id, err := strconv.ParseInt(movieID, 10, 64)
+		if err != nil {
+			return *pq, err
+		}
+		pq.MovieID = id
+	}
+
+	if format := qs.Get("format"); format != "" {
+		pq.Format = format
+	}
+
+	if minPrice := qs.Get("min_price"); minPrice != "" {
+		p, err := strconv.ParseFloat(minPrice, 64)
+		if err != nil {
+			return *pq, err
+		}
+		pq.MinPrice = &p
+	}
+
+	if maxPrice := qs.Get("max_price"); maxPrice != "" {
+		p, err := strconv.ParseFloat(maxPrice, 64)
+		if err != nil {
+			return *pq, err
+		}
+		pq.MaxPrice = &p
+	}
+
+	return *pq, nil
+}
+
 func parseDate(s string) string {
 	t, err := time.Parse("2006-01-02", s)
 	if err != nil {
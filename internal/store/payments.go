@@ -0,0 +1,517 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDuplicatePaymentEvent = errors.New("the payment event has already been processed")
+	ErrOrderNotPending       = errors.New("the order is no longer pending")
+	ErrPartiallyRefunded     = errors.New("the payment has been refunded ticket by ticket")
+)
+
+const (
+	PaymentStatusPending   = "pending"
+	PaymentStatusConfirmed = "confirmed"
+	PaymentStatusFailed    = "failed"
+	PaymentStatusRefunded  = "refunded"
+)
+
+// ErasedPayload replaces the raw payloads of the payment events of deleted
+// accounts.
+const ErasedPayload = "erased"
+
+type Payment struct {
+	ID              int64   `json:"id"`
+	OrderID         string  `json:"order_id"`
+	Provider        string  `json:"provider"`
+	ProviderOrderID string  `json:"provider_order_id"`
+	Amount          float64 `json:"amount"`
+	Currency        string  `json:"currency"`
+	Status          string  `json:"status"`
+	CheckoutURL     *string `json:"checkout_url,omitempty"`
+	CreatedAt       string  `json:"created_at"`
+	UpdatedAt       string  `json:"updated_at"`
+}
+
+// PaymentEvent is a single message received from a payment provider about a
+// payment, kept verbatim for reconciliation. FromStatus and ToStatus record
+// the transition it caused, if any.
+type PaymentEvent struct {
+	ID                    int64    `json:"id"`
+	PaymentID             *int64   `json:"payment_id,omitempty"`
+	Provider              string   `json:"provider"`
+	ProviderOrderID       *string  `json:"provider_order_id,omitempty"`
+	ProviderTransactionID *string  `json:"provider_transaction_id,omitempty"`
+	ProviderStatus        *string  `json:"provider_status,omitempty"`
+	Amount                *float64 `json:"amount,omitempty"`
+	Currency              *string  `json:"currency,omitempty"`
+	SignatureValid        bool     `json:"signature_valid"`
+	FromStatus            *string  `json:"from_status,omitempty"`
+	ToStatus              *string  `json:"to_status,omitempty"`
+	RawPayload            string   `json:"raw_payload"`
+	CreatedAt             string   `json:"created_at"`
+}
+
+type PaymentStore struct {
+	db *sql.DB
+}
+
+func (s *PaymentStore) GetByID(ctx context.Context, id int64) (*Payment, error) {
+	query := `
+		SELECT id, order_id, provider, provider_order_id, amount, currency, status, checkout_url, created_at, updated_at
+		FROM payments
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	payment := &Payment{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&payment.ID, &payment.OrderID, &payment.Provider, &payment.ProviderOrderID, &payment.Amount,
+		&payment.Currency, &payment.Status, &payment.CheckoutURL, &payment.CreatedAt, &payment.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return payment, nil
+}
+
+func (s *PaymentStore) GetByProviderOrderID(ctx context.Context, provider, providerOrderID string) (*Payment, error) {
+	query := `
+		SELECT id, order_id, provider, provider_order_id, amount, currency, status, checkout_url, created_at, updated_at
+		FROM payments
+		WHERE provider = $1 AND provider_order_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	payment := &Payment{}
+	err := s.db.QueryRowContext(ctx, query, provider, providerOrderID).Scan(
+		&payment.ID, &payment.OrderID, &payment.Provider, &payment.ProviderOrderID, &payment.Amount,
+		&payment.Currency, &payment.Status, &payment.CheckoutURL, &payment.CreatedAt, &payment.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return payment, nil
+}
+
+// GetByOrderID returns the latest payment attempt for the order.
+func (s *PaymentStore) GetByOrderID(ctx context.Context, orderID string) (*Payment, error) {
+	query := `
+		SELECT id, order_id, provider, provider_order_id, amount, currency, status, checkout_url, created_at, updated_at
+		FROM payments
+		WHERE order_id = $1
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	payment := &Payment{}
+	err := s.db.QueryRowContext(ctx, query, orderID).Scan(
+		&payment.ID, &payment.OrderID, &payment.Provider, &payment.ProviderOrderID, &payment.Amount,
+		&payment.Currency, &payment.Status, &payment.CheckoutURL, &payment.CreatedAt, &payment.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return payment, nil
+}
+
+func (s *PaymentStore) GetList(ctx context.Context, fq PaginatedPaymentsQuery) ([]Payment, int, error) {
+	query := `
+		SELECT id, order_id, provider, provider_order_id, amount, currency, status, checkout_url, created_at, updated_at,
+		       COUNT(*) OVER() AS total_count
+		FROM payments
+		WHERE
+			($1 = '' OR status = $1) AND
+			($2::date IS NULL OR created_at >= $2) AND
+			($3::date IS NULL OR created_at < $3::date + 1)
+		ORDER BY created_at ` + fq.Sort + `, id ` + fq.Sort + `
+		LIMIT $4 OFFSET $5
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, fq.Status, fq.Since, fq.Until, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var payments []Payment
+	totalCount := 0
+
+	for rows.Next() {
+		var payment Payment
+		err := rows.Scan(
+			&payment.ID, &payment.OrderID, &payment.Provider, &payment.ProviderOrderID, &payment.Amount,
+			&payment.Currency, &payment.Status, &payment.CheckoutURL, &payment.CreatedAt, &payment.UpdatedAt,
+			&totalCount,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		payments = append(payments, payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return payments, totalCount, nil
+}
+
+// GetPending returns pending payments of the given provider created within
+// the [since, until) window, oldest first.
+func (s *PaymentStore) GetPending(ctx context.Context, provider string, since, until time.Time) ([]Payment, error) {
+	query := `
+		SELECT id, order_id, provider, provider_order_id, amount, currency, status, checkout_url, created_at, updated_at
+		FROM payments
+		WHERE provider = $1 AND status = $2 AND created_at >= $3 AND created_at < $4
+		ORDER BY created_at, id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, provider, PaymentStatusPending, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []Payment
+	for rows.Next() {
+		var payment Payment
+		err := rows.Scan(
+			&payment.ID, &payment.OrderID, &payment.Provider, &payment.ProviderOrderID, &payment.Amount,
+			&payment.Currency, &payment.Status, &payment.CheckoutURL, &payment.CreatedAt, &payment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// GetByUserID returns every payment attempt for the orders of the user, newest
+// first.
+func (s *PaymentStore) GetByUserID(ctx context.Context, userID int64) ([]Payment, error) {
+	query := `
+		SELECT p.id, p.order_id, p.provider, p.provider_order_id, p.amount, p.currency, p.status, p.checkout_url, p.created_at, p.updated_at
+		FROM payments p
+		JOIN orders o ON o.id = p.order_id
+		WHERE o.user_id = $1
+		ORDER BY p.created_at DESC, p.id DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []Payment
+	for rows.Next() {
+		var payment Payment
+		err := rows.Scan(
+			&payment.ID, &payment.OrderID, &payment.Provider, &payment.ProviderOrderID, &payment.Amount,
+			&payment.Currency, &payment.Status, &payment.CheckoutURL, &payment.CreatedAt, &payment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (s *PaymentStore) GetEvents(ctx context.Context, paymentID int64) ([]PaymentEvent, error) {
+	query := `
+		SELECT id, payment_id, provider, provider_order_id, provider_transaction_id, provider_status,
+		       amount, currency, signature_valid, from_status, to_status, raw_payload, created_at
+		FROM payment_events
+		WHERE payment_id = $1
+		ORDER BY id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []PaymentEvent
+	for rows.Next() {
+		var event PaymentEvent
+		err := rows.Scan(
+			&event.ID, &event.PaymentID, &event.Provider, &event.ProviderOrderID, &event.ProviderTransactionID, &event.ProviderStatus,
+			&event.Amount, &event.Currency, &event.SignatureValid, &event.FromStatus, &event.ToStatus, &event.RawPayload, &event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (s *PaymentStore) Create(ctx context.Context, payment *Payment) error {
+	query := `
+		INSERT INTO payments (order_id, provider, provider_order_id, amount, currency, status, checkout_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, status, created_at, updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	status := payment.Status
+	if status == "" {
+		status = PaymentStatusPending
+	}
+
+	return s.db.QueryRowContext(
+		ctx, query,
+		payment.OrderID, payment.Provider, payment.ProviderOrderID, payment.Amount, payment.Currency, status, payment.CheckoutURL,
+	).Scan(&payment.ID, &payment.Status, &payment.CreatedAt, &payment.UpdatedAt)
+}
+
+// Update saves the provider details of a payment. Status changes go through
+// Settle so that they follow the payment state machine.
+func (s *PaymentStore) Update(ctx context.Context, payment *Payment) error {
+	query := `
+		UPDATE payments SET checkout_url = $1, updated_at = NOW()
+		WHERE id = $2 RETURNING updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, payment.CheckoutURL, payment.ID).Scan(&payment.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordEvent stores a provider message that did not change any status, e.g.
+// one with an invalid signature or for an unknown order.
+func (s *PaymentStore) RecordEvent(ctx context.Context, event *PaymentEvent) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return s.createEvent(ctx, tx, event)
+	})
+}
+
+// Settle records the event and, in the same transaction, moves the payment to
+// event.ToStatus. A confirmed, failed or refunded payment confirms, releases or
+// refunds every ticket of its order.
+//
+// It returns ErrDuplicatePaymentEvent if the provider already sent the same
+// event and ErrInvalidTransition if the payment is not in a state that allows
+// the transition; nothing is changed in both cases. If the payment succeeded
+// after its order had already been released, the payment is still confirmed
+// and ErrOrderNotPending is returned so the caller can arrange a refund.
+//
+// Providers report a payment as refunded after any refund, including the
+// refund of a single ticket. Once tickets of the payment have been refunded on
+// their own, such a report is recorded without changing anything and
+// ErrPartiallyRefunded is returned: each of those refunds settles its own
+// ticket, and the payment becomes refunded with the last of them.
+func (s *PaymentStore) Settle(ctx context.Context, payment *Payment, event *PaymentEvent) error {
+	orderPending := true
+	partiallyRefunded := false
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		from := payment.Status
+		event.PaymentID = &payment.ID
+		event.FromStatus = &from
+
+		if event.ToStatus != nil && *event.ToStatus == PaymentStatusRefunded && from == PaymentStatusConfirmed {
+			var err error
+			partiallyRefunded, err = hasTicketRefunds(ctx, tx, payment.ID)
+			if err != nil {
+				return err
+			}
+
+			if partiallyRefunded {
+				event.ToStatus = nil
+			}
+		}
+
+		if err := s.createEvent(ctx, tx, event); err != nil {
+			return err
+		}
+
+		if event.ToStatus == nil || *event.ToStatus == from {
+			return nil
+		}
+
+		if !CanTransitionPayment(from, *event.ToStatus) {
+			return ErrInvalidTransition
+		}
+
+		if err := s.setStatus(ctx, tx, payment, from, *event.ToStatus); err != nil {
+			return err
+		}
+
+		var err error
+		switch payment.Status {
+		case PaymentStatusConfirmed:
+			err = settleOrder(ctx, tx, payment.OrderID, OrderStatusConfirmed, TicketStatusConfirmed)
+		case PaymentStatusFailed:
+			err = settleOrder(ctx, tx, payment.OrderID, OrderStatusFailed, TicketStatusFailed)
+		case PaymentStatusRefunded:
+			err = refundOrder(ctx, tx, payment.OrderID)
+		}
+
+		if errors.Is(err, ErrInvalidTransition) {
+			orderPending = false
+			return nil
+		}
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if !orderPending && payment.Status == PaymentStatusConfirmed {
+		return ErrOrderNotPending
+	}
+
+	if partiallyRefunded {
+		return ErrPartiallyRefunded
+	}
+
+	return nil
+}
+
+// hasTicketRefunds reports whether single tickets of the payment have been or
+// are being refunded.
+func hasTicketRefunds(ctx context.Context, tx *sql.Tx, paymentID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM refunds
+			WHERE payment_id = $1 AND ticket_id IS NOT NULL AND status IN ($2, $3)
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var exists bool
+	err := tx.QueryRowContext(ctx, query, paymentID, RefundStatusPending, RefundStatusSucceeded).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (s *PaymentStore) setStatus(ctx context.Context, tx *sql.Tx, payment *Payment, from, to string) error {
+	query := `
+		UPDATE payments SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status = $3
+		RETURNING status, updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := tx.QueryRowContext(ctx, query, to, payment.ID, from).Scan(&payment.Status, &payment.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrInvalidTransition
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *PaymentStore) createEvent(ctx context.Context, tx *sql.Tx, event *PaymentEvent) error {
+	query := `
+		INSERT INTO payment_events (
+			payment_id, provider, provider_order_id, provider_transaction_id, provider_status,
+			amount, currency, signature_valid, from_status, to_status, raw_payload
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := tx.QueryRowContext(
+		ctx, query,
+		event.PaymentID, event.Provider, event.ProviderOrderID, event.ProviderTransactionID, event.ProviderStatus,
+		event.Amount, event.Currency, event.SignatureValid, event.FromStatus, event.ToStatus, event.RawPayload,
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "payment_events_provider_transaction_key"`:
+			return ErrDuplicatePaymentEvent
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/lib/pq"
+)
+
+// testStorage connects to the database in TEST_DB_ADDR and migrates it. Tests
+// needing a database are skipped without one.
+func testStorage(t *testing.T) Storage {
+	t.Helper()
+
+	addr := os.Getenv("TEST_DB_ADDR")
+	if addr == "" {
+		t.Skip("TEST_DB_ADDR is not set")
+	}
+
+	m, err := migrate.New("file://../../cmd/migrate/migrations", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("postgres", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewStorage(db)
+}
+
+func TestSettleReversalAfterTicketRefund(t *testing.T) {
+	s := testStorage(t)
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+
+	room := &Room{Name: fmt.Sprintf("test-room-%d", suffix), Capacity: 2}
+	if err := s.Rooms.Create(ctx, room); err != nil {
+		t.Fatal(err)
+	}
+
+	var seats []*Seat
+	for n := int64(1); n <= 2; n++ {
+		seat := &Seat{RoomID: room.ID, Row: 1, Number: n, Type: SeatTypeStandard}
+		if err := s.Seats.Create(ctx, seat); err != nil {
+			t.Fatal(err)
+		}
+		seats = append(seats, seat)
+	}
+
+	movie := &Movie{
+		Slug:        fmt.Sprintf("test-movie-%d", suffix),
+		Title:       "Test movie",
+		Description: "Test movie",
+		Duration:    90,
+		ReleaseDate: "2026-01-01",
+	}
+	if err := s.Movies.Create(ctx, movie); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Add(48 * time.Hour).UTC()
+	session := &Session{
+		MovieID:       movie.ID,
+		RoomID:        room.ID,
+		StartTime:     start.Format(time.RFC3339),
+		EndTime:       start.Add(90 * time.Minute).Format(time.RFC3339),
+		OccupiedUntil: start.Add(105 * time.Minute).Format(time.RFC3339),
+		Format:        SessionFormat2D,
+		Price:         100,
+	}
+	if err := s.Sessions.Create(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+
+	order := &Order{Amount: 200, Currency: "UAH"}
+	for _, seat := range seats {
+		order.Tickets = append(order.Tickets, Ticket{SessionID: session.ID, SeatID: seat.ID, Price: 100})
+	}
+	if err := s.Orders.Create(ctx, order, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	payment := &Payment{
+		OrderID:         order.ID,
+		Provider:        "test",
+		ProviderOrderID: fmt.Sprintf("test-%d", suffix),
+		Amount:          order.Amount,
+		Currency:        order.Currency,
+	}
+	if err := s.Payments.Create(ctx, payment); err != nil {
+		t.Fatal(err)
+	}
+
+	settle := func(status string) error {
+		return s.Payments.Settle(ctx, payment, &PaymentEvent{
+			Provider:        payment.Provider,
+			ProviderOrderID: &payment.ProviderOrderID,
+			ProviderStatus:  &status,
+			ToStatus:        &status,
+			RawPayload:      "{}",
+		})
+	}
+
+	if err := settle(PaymentStatusConfirmed); err != nil {
+		t.Fatal(err)
+	}
+
+	cancelled, kept := order.Tickets[0].ID, order.Tickets[1].ID
+
+	refund := &Refund{PaymentID: payment.ID, TicketID: &cancelled, Amount: 100, Reason: "cancelled by customer"}
+	if err := s.Refunds.Create(ctx, refund); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Refunds.Complete(ctx, refund); err != nil {
+		t.Fatal(err)
+	}
+
+	// The provider reports the payment as reversed after the partial refund.
+	if err := settle(PaymentStatusRefunded); !errors.Is(err, ErrPartiallyRefunded) {
+		t.Fatalf("settling the reversal: got %v, want %v", err, ErrPartiallyRefunded)
+	}
+
+	if payment.Status != PaymentStatusConfirmed {
+		t.Errorf("payment status = %q, want %q", payment.Status, PaymentStatusConfirmed)
+	}
+
+	ticket, err := s.Tickets.GetByID(ctx, kept)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket.Status != TicketStatusConfirmed {
+		t.Errorf("status of the ticket that was not cancelled = %q, want %q", ticket.Status, TicketStatusConfirmed)
+	}
+
+	// Cancelling the other ticket still works and refunds the whole payment.
+	refund = &Refund{PaymentID: payment.ID, TicketID: &kept, Amount: 100, Reason: "cancelled by customer"}
+	if err := s.Refunds.Create(ctx, refund); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Refunds.Complete(ctx, refund); err != nil {
+		t.Fatalf("refunding the other ticket: %v", err)
+	}
+
+	payment, err = s.Payments.GetByID(ctx, payment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment.Status != PaymentStatusRefunded {
+		t.Errorf("payment status = %q, want %q", payment.Status, PaymentStatusRefunded)
+	}
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrDuplicateRefund = errors.New("a refund for that ticket already exists")
+)
+
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+)
+
+// Refund is money returned for a single ticket or, when TicketID is nil, for
+// the whole payment.
+type Refund struct {
+	ID               int64   `json:"id"`
+	PaymentID        int64   `json:"payment_id"`
+	TicketID         *string `json:"ticket_id,omitempty"`
+	Amount           float64 `json:"amount"`
+	Status           string  `json:"status"`
+	Reason           string  `json:"reason"`
+	ProviderResponse *string `json:"provider_response,omitempty"`
+	CreatedAt        string  `json:"created_at"`
+	UpdatedAt        string  `json:"updated_at"`
+}
+
+type RefundStore struct {
+	db *sql.DB
+}
+
+func (s *RefundStore) GetByPaymentID(ctx context.Context, paymentID int64) ([]Refund, error) {
+	query := `
+		SELECT id, payment_id, ticket_id, amount, status, reason, provider_response, created_at, updated_at
+		FROM refunds
+		WHERE payment_id = $1
+		ORDER BY id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []Refund
+	for rows.Next() {
+		var refund Refund
+		err := rows.Scan(
+			&refund.ID, &refund.PaymentID, &refund.TicketID, &refund.Amount, &refund.Status,
+			&refund.Reason, &refund.ProviderResponse, &refund.CreatedAt, &refund.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return refunds, nil
+}
+
+// Create registers a pending refund before the provider is asked to return
+// the money, so that a ticket can never be refunded twice.
+func (s *RefundStore) Create(ctx context.Context, refund *Refund) error {
+	query := `
+		INSERT INTO refunds (payment_id, ticket_id, amount, status, reason)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, status, created_at, updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx, query,
+		refund.PaymentID, refund.TicketID, refund.Amount, RefundStatusPending, refund.Reason,
+	).Scan(&refund.ID, &refund.Status, &refund.CreatedAt, &refund.UpdatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "refunds_ticket_id_key"`,
+			err.Error() == `pq: duplicate key value violates unique constraint "refunds_payment_id_key"`:
+			return ErrDuplicateRefund
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Complete marks the refund as succeeded and refunds its ticket. Once no paid
+// ticket is left in the order, the order and its payment become refunded as
+// well. A refund without a ticket refunds the whole payment at once.
+func (s *RefundStore) Complete(ctx context.Context, refund *Refund) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.setStatus(ctx, tx, refund, RefundStatusSucceeded); err != nil {
+			return err
+		}
+
+		var orderID string
+		err := tx.QueryRowContext(ctx, `SELECT order_id FROM payments WHERE id = $1`, refund.PaymentID).Scan(&orderID)
+		if err != nil {
+			return err
+		}
+
+		if refund.TicketID != nil {
+			res, err := tx.ExecContext(
+				ctx,
+				`UPDATE tickets SET status = $1 WHERE id = $2 AND status = $3`,
+				TicketStatusRefunded, *refund.TicketID, TicketStatusConfirmed,
+			)
+			if err != nil {
+				return err
+			}
+
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if rows == 0 {
+				return ErrInvalidTransition
+			}
+
+			var paid int
+			err = tx.QueryRowContext(
+				ctx,
+				`SELECT COUNT(*) FROM tickets WHERE order_id = $1 AND status IN ($2, $3)`,
+				orderID, TicketStatusConfirmed, TicketStatusUsed,
+			).Scan(&paid)
+			if err != nil {
+				return err
+			}
+
+			if paid > 0 {
+				return nil
+			}
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE payments SET status = $1, updated_at = NOW() WHERE id = $2 AND status = $3`,
+			PaymentStatusRefunded, refund.PaymentID, PaymentStatusConfirmed,
+		)
+		if err != nil {
+			return err
+		}
+
+		return refundOrder(ctx, tx, orderID)
+	})
+}
+
+// Fail marks the refund as failed so that it can be retried.
+func (s *RefundStore) Fail(ctx context.Context, refund *Refund) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return s.setStatus(ctx, tx, refund, RefundStatusFailed)
+	})
+}
+
+func (s *RefundStore) setStatus(ctx context.Context, tx *sql.Tx, refund *Refund, status string) error {
+	query := `
+		UPDATE refunds SET status = $1, provider_response = $2, updated_at = NOW()
+		WHERE id = $3 AND status = $4
+		RETURNING status, updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := tx.QueryRowContext(
+		ctx, query,
+		status, refund.ProviderResponse, refund.ID, RefundStatusPending,
+	).Scan(&refund.Status, &refund.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrInvalidTransition
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
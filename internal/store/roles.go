@@ -4,13 +4,28 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrDuplicateRole     = errors.New("a role with that name already exists")
+	ErrRoleInUse         = errors.New("the role is assigned to users")
+	ErrUnknownPermission = errors.New("unknown permission")
 )
 
 type Role struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	RequiresMFA bool     `json:"requires_mfa"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+type Permission struct {
 	ID          int64  `json:"id"`
 	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
-	Level       int    `json:"level,omitempty"`
+	Description string `json:"description"`
 }
 
 type RolesStore struct {
@@ -18,7 +33,7 @@ type RolesStore struct {
 }
 
 func (s *RolesStore) GetByName(ctx context.Context, name string) (*Role, error) {
-	query := `SELECT id, name, description, level  FROM roles WHERE name = $1`
+	query := `SELECT id, name, description, requires_mfa FROM roles WHERE name = $1`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
@@ -28,7 +43,7 @@ func (s *RolesStore) GetByName(ctx context.Context, name string) (*Role, error)
 		&role.ID,
 		&role.Name,
 		&role.Description,
-		&role.Level,
+		&role.RequiresMFA,
 	)
 	if err != nil {
 		switch {
@@ -41,3 +56,273 @@ func (s *RolesStore) GetByName(ctx context.Context, name string) (*Role, error)
 
 	return role, nil
 }
+
+// GetByID returns the role together with its permissions.
+func (s *RolesStore) GetByID(ctx context.Context, id int64) (*Role, error) {
+	query := `
+		SELECT r.id, r.name, COALESCE(r.description, ''), r.requires_mfa,
+			COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		WHERE r.id = $1
+		GROUP BY r.id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	role := &Role{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&role.ID,
+		&role.Name,
+		&role.Description,
+		&role.RequiresMFA,
+		pq.Array(&role.Permissions),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return role, nil
+}
+
+// GetAll returns every role together with its permissions.
+func (s *RolesStore) GetAll(ctx context.Context) ([]Role, error) {
+	query := `
+		SELECT r.id, r.name, COALESCE(r.description, ''), r.requires_mfa,
+			COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		GROUP BY r.id
+		ORDER BY r.id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []Role
+	for rows.Next() {
+		var role Role
+		err := rows.Scan(
+			&role.ID,
+			&role.Name,
+			&role.Description,
+			&role.RequiresMFA,
+			pq.Array(&role.Permissions),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// Create stores the role together with its permissions. It returns
+// ErrUnknownPermission if any of them does not exist.
+func (s *RolesStore) Create(ctx context.Context, role *Role) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		err := tx.QueryRowContext(
+			ctx,
+			`INSERT INTO roles (name, description, requires_mfa) VALUES ($1, $2, $3) RETURNING id`,
+			role.Name, role.Description, role.RequiresMFA,
+		).Scan(&role.ID)
+		if err != nil {
+			switch {
+			case err.Error() == `pq: duplicate key value violates unique constraint "roles_name_key"`:
+				return ErrDuplicateRole
+			default:
+				return err
+			}
+		}
+
+		return setRolePermissions(ctx, tx, role.ID, role.Permissions)
+	})
+}
+
+func (s *RolesStore) Update(ctx context.Context, role *Role) error {
+	query := `UPDATE roles SET description = $1, requires_mfa = $2 WHERE id = $3`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, role.Description, role.RequiresMFA, role.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// SetPermissions replaces the permissions of the role. It returns
+// ErrUnknownPermission if any of them does not exist.
+func (s *RolesStore) SetPermissions(ctx context.Context, roleID int64, permissions []string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return setRolePermissions(ctx, tx, roleID, permissions)
+	})
+}
+
+// Delete removes the role. It returns ErrRoleInUse while users still have it.
+func (s *RolesStore) Delete(ctx context.Context, id int64) error {
+	query := `DELETE FROM roles WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: update or delete on table "roles" violates foreign key constraint "users_role_id_fkey" on table "users"`:
+			return ErrRoleInUse
+		default:
+			return err
+		}
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Includes reports whether the role grants every permission of the other
+// role.
+func (s *RolesStore) Includes(ctx context.Context, roleID, otherRoleID int64) (bool, error) {
+	query := `
+		SELECT NOT EXISTS (
+			SELECT permission_id FROM role_permissions WHERE role_id = $2
+			EXCEPT
+			SELECT permission_id FROM role_permissions WHERE role_id = $1
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var includes bool
+	if err := s.db.QueryRowContext(ctx, query, roleID, otherRoleID).Scan(&includes); err != nil {
+		return false, err
+	}
+
+	return includes, nil
+}
+
+// HasPermission reports whether the role grants the permission.
+func (s *RolesStore) HasPermission(ctx context.Context, roleID int64, permission string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM role_permissions rp
+			JOIN permissions p ON p.id = rp.permission_id
+			WHERE rp.role_id = $1 AND p.name = $2
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var allowed bool
+	if err := s.db.QueryRowContext(ctx, query, roleID, permission).Scan(&allowed); err != nil {
+		return false, err
+	}
+
+	return allowed, nil
+}
+
+func (s *RolesStore) GetPermissions(ctx context.Context) ([]Permission, error) {
+	query := `SELECT id, name, description FROM permissions ORDER BY name`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []Permission
+	for rows.Next() {
+		var p Permission
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description); err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func setRolePermissions(ctx context.Context, tx *sql.Tx, roleID int64, permissions []string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return err
+	}
+
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO role_permissions (role_id, permission_id) SELECT $1, id FROM permissions WHERE name = ANY($2)`,
+		roleID, pq.Array(permissions),
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows != int64(len(permissions)) {
+		return ErrUnknownPermission
+	}
+
+	return nil
+}
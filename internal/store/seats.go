@@ -10,29 +10,47 @@ var (
 	ErrDuplicateSeat = errors.New("a seat with that row and number already exists")
 )
 
+const (
+	SeatTypeStandard   = "standard"
+	SeatTypeVIP        = "vip"
+	SeatTypeLoveSeat   = "love_seat"
+	SeatTypeAccessible = "accessible"
+)
+
 type Seat struct {
-	ID     int64 `json:"id"`
-	RoomID int64 `json:"room_id"`
-	Row    int64 `json:"row"`
-	Number int64 `json:"seat_number"`
+	ID     int64  `json:"id"`
+	RoomID int64  `json:"room_id"`
+	Row    int64  `json:"row"`
+	Number int64  `json:"seat_number"`
+	Type   string `json:"type"`
 }
 
+// SeatWithMetadata is a seat as offered at a session. Price is the price of
+// the seat type at the session.
 type SeatWithMetadata struct {
-	ID     int64    `json:"id"`
-	RoomID int64    `json:"room_id"`
-	Row    int64    `json:"row"`
-	Number int64    `json:"seat_number"`
-	Price  *float64 `json:"price,omitempty"`
-	Status string   `json:"status"`
+	ID        int64    `json:"id"`
+	RoomID    int64    `json:"room_id"`
+	Row       int64    `json:"row"`
+	Number    int64    `json:"seat_number"`
+	Type      string   `json:"type"`
+	Price     *float64 `json:"price,omitempty"`
+	Status    string   `json:"status"`
+	HeldUntil *string  `json:"held_until,omitempty"`
 }
 
+const (
+	SeatStatusAvailable = "available"
+	SeatStatusHeld      = "held"
+	SeatStatusReserved  = "reserved"
+)
+
 type SeatStore struct {
 	db *sql.DB
 }
 
 func (s *SeatStore) GetByID(ctx context.Context, id int64) (*Seat, error) {
 	query := `
-		SELECT id, room_id, row, seat_number
+		SELECT id, room_id, row, seat_number, type
 		FROM seats
 		WHERE id = $1
 	`
@@ -43,7 +61,7 @@ func (s *SeatStore) GetByID(ctx context.Context, id int64) (*Seat, error) {
 	seat := &Seat{}
 
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&seat.ID, &seat.RoomID, &seat.Row, &seat.Number,
+		&seat.ID, &seat.RoomID, &seat.Row, &seat.Number, &seat.Type,
 	)
 	if err != nil {
 		switch {
@@ -59,17 +77,19 @@ func (s *SeatStore) GetByID(ctx context.Context, id int64) (*Seat, error) {
 
 func (s *SeatStore) GetBySession(ctx context.Context, sessionID int64) ([]SeatWithMetadata, error) {
 	query := `
-		SELECT s.id, s.room_id, s.row, s.seat_number, t.user_id, ses.price
+		SELECT s.id, s.room_id, s.row, s.seat_number, s.type, t.status, h.expires_at, COALESCE(sp.price, ses.price)
 		FROM seats s
 		JOIN sessions ses ON s.room_id = ses.room_id
-		LEFT JOIN tickets t ON s.id = t.seat_id AND ses.id = t.session_id
+		LEFT JOIN session_prices sp ON sp.session_id = ses.id AND sp.seat_type = s.type
+		LEFT JOIN tickets t ON s.id = t.seat_id AND ses.id = t.session_id AND t.status IN ($2, $3, $4)
+		LEFT JOIN seat_holds h ON h.ticket_id = t.id
 		WHERE ses.id = $1
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	rows, err := s.db.QueryContext(ctx, query, sessionID)
+	rows, err := s.db.QueryContext(ctx, query, sessionID, TicketStatusPending, TicketStatusConfirmed, TicketStatusUsed)
 	if err != nil {
 		return nil, err
 	}
@@ -78,18 +98,21 @@ func (s *SeatStore) GetBySession(ctx context.Context, sessionID int64) ([]SeatWi
 	var seats []SeatWithMetadata
 	for rows.Next() {
 		var seat SeatWithMetadata
-		var userID *int64
-		if err := rows.Scan(&seat.ID, &seat.RoomID, &seat.Row, &seat.Number, &userID, &seat.Price); err != nil {
+		var ticketStatus *string
+		if err := rows.Scan(&seat.ID, &seat.RoomID, &seat.Row, &seat.Number, &seat.Type, &ticketStatus, &seat.HeldUntil, &seat.Price); err != nil {
 			return nil, err
 		}
 
-		seatStatus := "available"
-		if userID != nil {
-			seatStatus = "reserved"
+		seat.Status = SeatStatusAvailable
+		if ticketStatus != nil {
+			switch *ticketStatus {
+			case TicketStatusPending:
+				seat.Status = SeatStatusHeld
+			default:
+				seat.Status = SeatStatusReserved
+			}
 		}
 
-		seat.Status = seatStatus
-
 		seats = append(seats, seat)
 	}
 
@@ -102,8 +125,8 @@ func (s *SeatStore) GetBySession(ctx context.Context, sessionID int64) ([]SeatWi
 
 func (s *SeatStore) Create(ctx context.Context, seat *Seat) error {
 	query := `
-		INSERT INTO seats (room_id, row, seat_number)
-		VALUES ($1, $2, $3) RETURNING id
+		INSERT INTO seats (room_id, row, seat_number, type)
+		VALUES ($1, $2, $3, $4) RETURNING id
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
@@ -111,7 +134,7 @@ func (s *SeatStore) Create(ctx context.Context, seat *Seat) error {
 
 	err := s.db.QueryRowContext(
 		ctx, query,
-		seat.RoomID, seat.Row, seat.Number,
+		seat.RoomID, seat.Row, seat.Number, seat.Type,
 	).Scan(&seat.ID)
 
 	if err != nil {
@@ -150,7 +173,7 @@ func (s *SeatStore) Delete(ctx context.Context, id int64) error {
 
 func (s *SeatStore) Update(ctx context.Context, seat *Seat) error {
 	query := `
-		UPDATE seats SET row = $1, seat_number = $2 WHERE id = $3 
+		UPDATE seats SET row = $1, seat_number = $2, type = $3 WHERE id = $4
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
@@ -158,7 +181,7 @@ func (s *SeatStore) Update(ctx context.Context, seat *Seat) error {
 
 	res, err := s.db.ExecContext(
 		ctx, query,
-		seat.Row, seat.Number,
+		seat.Row, seat.Number, seat.Type,
 		seat.ID,
 	)
 	if err != nil {
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// SessionPrice is the price of every seat of a type at a session.
+type SessionPrice struct {
+	SeatType string  `json:"seat_type"`
+	Price    float64 `json:"price"`
+}
+
+// PriceFor returns the price of a seat of the given type at the session.
+// Types without a price of their own cost the price of the session.
+func (s *Session) PriceFor(seatType string) float64 {
+	for _, p := range s.Prices {
+		if p.SeatType == seatType {
+			return p.Price
+		}
+	}
+	return s.Price
+}
+
+// GetPrices returns the seat type prices of the session.
+func (s *SessionStore) GetPrices(ctx context.Context, sessionID int64) ([]SessionPrice, error) {
+	prices, err := getSessionPrices(ctx, s.db, []int64{sessionID})
+	if err != nil {
+		return nil, err
+	}
+
+	return prices[sessionID], nil
+}
+
+// SetPrices replaces the seat type prices of the session.
+func (s *SessionStore) SetPrices(ctx context.Context, sessionID int64, prices []SessionPrice) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return setSessionPrices(ctx, tx, sessionID, prices)
+	})
+}
+
+func setSessionPrices(ctx context.Context, tx *sql.Tx, sessionID int64, prices []SessionPrice) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM session_prices WHERE session_id = $1`, sessionID); err != nil {
+		return err
+	}
+
+	for _, p := range prices {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO session_prices (session_id, seat_type, price) VALUES ($1, $2, $3)`,
+			sessionID, p.SeatType, p.Price,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getSessionPrices returns the seat type prices of each of the sessions.
+func getSessionPrices(ctx context.Context, db *sql.DB, sessionIDs []int64) (map[int64][]SessionPrice, error) {
+	query := `
+		SELECT session_id, seat_type, price
+		FROM session_prices
+		WHERE session_id = ANY($1)
+		ORDER BY session_id, seat_type
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, query, pq.Array(sessionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := make(map[int64][]SessionPrice)
+	for rows.Next() {
+		var sessionID int64
+		var p SessionPrice
+		if err := rows.Scan(&sessionID, &p.SeatType, &p.Price); err != nil {
+			return nil, err
+		}
+		prices[sessionID] = append(prices[sessionID], p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
@@ -4,16 +4,46 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 )
 
+var (
+	ErrSessionConflict   = errors.New("the room is already booked at that time")
+	ErrSessionCancelled  = errors.New("the session has been cancelled")
+	ErrSessionHasTickets = errors.New("the session has tickets and can only be cancelled")
+)
+
+const (
+	SessionStatusScheduled = "scheduled"
+	SessionStatusCancelled = "cancelled"
+)
+
+const (
+	SessionFormat2D   = "2D"
+	SessionFormat3D   = "3D"
+	SessionFormatIMAX = "IMAX"
+	SessionFormat4DX  = "4DX"
+)
+
+// Session is a screening of a movie in a room. The room stays occupied from
+// StartTime until OccupiedUntil, which is EndTime plus the time needed to
+// clean the room; sessions of a room never overlap. A cancelled session frees
+// its room and is kept only for the record.
 type Session struct {
-	ID        int64   `json:"id"`
-	MovieID   int64   `json:"movie_id"`
-	RoomID    int64   `json:"room_id"`
-	StartTime string  `json:"start_time"`
-	Price     float64 `json:"price"`
-	Movie     Movie   `json:"movie"`
-	Room      Room    `json:"room"`
+	ID                 int64          `json:"id"`
+	MovieID            int64          `json:"movie_id"`
+	RoomID             int64          `json:"room_id"`
+	StartTime          string         `json:"start_time"`
+	EndTime            string         `json:"end_time"`
+	OccupiedUntil      string         `json:"-"`
+	Format             string         `json:"format"`
+	Price              float64        `json:"price"`
+	Status             string         `json:"status"`
+	CancelledAt        *string        `json:"cancelled_at,omitempty"`
+	CancellationReason *string        `json:"cancellation_reason,omitempty"`
+	Prices             []SessionPrice `json:"prices,omitempty"`
+	Movie              Movie          `json:"movie"`
+	Room               Room           `json:"room"`
 }
 
 type SessionWithoutMovie struct {
@@ -21,6 +51,8 @@ type SessionWithoutMovie struct {
 	MovieID   int64   `json:"movie_id"`
 	RoomID    int64   `json:"room_id"`
 	StartTime string  `json:"start_time"`
+	EndTime   string  `json:"end_time"`
+	Format    string  `json:"format"`
 	Price     float64 `json:"price"`
 	Room      Room    `json:"room"`
 }
@@ -31,7 +63,8 @@ type SessionStore struct {
 
 func (s *SessionStore) GetByID(ctx context.Context, id int64) (*Session, error) {
 	query := `
-		SELECT s.id, s.movie_id, s.room_id, s.start_time, s.price,
+		SELECT s.id, s.movie_id, s.room_id, s.start_time, s.end_time, s.occupied_until, s.format, s.price,
+		       s.status, s.cancelled_at, s.cancellation_reason,
 		       m.id, m.title, m.description, m.duration, m.release_date,
 		       r.id, r.name, r.capacity
 		FROM sessions s
@@ -46,7 +79,8 @@ func (s *SessionStore) GetByID(ctx context.Context, id int64) (*Session, error)
 	session := &Session{}
 
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&session.ID, &session.MovieID, &session.RoomID, &session.StartTime, &session.Price,
+		&session.ID, &session.MovieID, &session.RoomID, &session.StartTime, &session.EndTime, &session.OccupiedUntil, &session.Format, &session.Price,
+		&session.Status, &session.CancelledAt, &session.CancellationReason,
 		&session.Movie.ID, &session.Movie.Title, &session.Movie.Description, &session.Movie.Duration, &session.Movie.ReleaseDate,
 		&session.Room.ID, &session.Room.Name, &session.Room.Capacity,
 	)
@@ -59,23 +93,28 @@ func (s *SessionStore) GetByID(ctx context.Context, id int64) (*Session, error)
 		}
 	}
 
+	session.Prices, err = s.GetPrices(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return session, nil
 }
 
 func (s *SessionStore) GetByMovieID(ctx context.Context, movieID int64) ([]SessionWithoutMovie, error) {
 	query := `
-		SELECT s.id, s.movie_id, s.room_id, s.start_time, s.price,
+		SELECT s.id, s.movie_id, s.room_id, s.start_time, s.end_time, s.format, s.price,
 		       r.id, r.name, r.capacity
 		FROM sessions s
 		LEFT JOIN rooms r ON s.room_id = r.id
-		WHERE s.movie_id = $1
+		WHERE s.movie_id = $1 AND s.status = $2
 		ORDER BY s.start_time;
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	rows, err := s.db.QueryContext(ctx, query, movieID)
+	rows, err := s.db.QueryContext(ctx, query, movieID, SessionStatusScheduled)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +125,7 @@ func (s *SessionStore) GetByMovieID(ctx context.Context, movieID int64) ([]Sessi
 	for rows.Next() {
 		var session SessionWithoutMovie
 		err := rows.Scan(
-			&session.ID, &session.MovieID, &session.RoomID, &session.StartTime, &session.Price,
+			&session.ID, &session.MovieID, &session.RoomID, &session.StartTime, &session.EndTime, &session.Format, &session.Price,
 			&session.Room.ID, &session.Room.Name, &session.Room.Capacity,
 		)
 		if err != nil {
@@ -106,51 +145,242 @@ func (s *SessionStore) GetByMovieID(ctx context.Context, movieID int64) ([]Sessi
 	return sessions, nil
 }
 
-func (s *SessionStore) Create(ctx context.Context, session *Session) error {
+// GetByRoom returns the sessions of the room starting within [since, until),
+// earliest first, with their seat type prices. Cancelled sessions are left
+// out.
+func (s *SessionStore) GetByRoom(ctx context.Context, roomID int64, since, until time.Time) ([]Session, error) {
 	query := `
-		INSERT INTO sessions (movie_id, room_id, start_time, price) 
-		VALUES ($1, $2, $3, $4) RETURNING id
+		SELECT s.id, s.movie_id, s.room_id, s.start_time, s.end_time, s.occupied_until, s.format, s.price,
+		       s.status, s.cancelled_at, s.cancellation_reason,
+		       m.id, m.title, m.description, m.duration, m.release_date,
+		       r.id, r.name, r.capacity
+		FROM sessions s
+		JOIN movies m ON s.movie_id = m.id
+		JOIN rooms r ON s.room_id = r.id
+		WHERE s.room_id = $1 AND s.start_time >= $2 AND s.start_time < $3 AND s.status = $4
+		ORDER BY s.start_time
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	err := s.db.QueryRowContext(
-		ctx, query,
-		session.MovieID, session.RoomID, session.StartTime, session.Price,
-	).Scan(&session.ID)
+	rows, err := s.db.QueryContext(ctx, query, roomID, since, until, SessionStatusScheduled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var session Session
+		err := rows.Scan(
+			&session.ID, &session.MovieID, &session.RoomID, &session.StartTime, &session.EndTime, &session.OccupiedUntil, &session.Format, &session.Price,
+			&session.Status, &session.CancelledAt, &session.CancellationReason,
+			&session.Movie.ID, &session.Movie.Title, &session.Movie.Description, &session.Movie.Duration, &session.Movie.ReleaseDate,
+			&session.Room.ID, &session.Room.Name, &session.Room.Capacity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(sessions))
+	for i := range sessions {
+		ids[i] = sessions[i].ID
+	}
+
+	prices, err := getSessionPrices(ctx, s.db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Prices = prices[sessions[i].ID]
+	}
 
-	return err
+	return sessions, nil
 }
 
-func (s *SessionStore) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM sessions WHERE id = $1`
+// GetConflicting returns the earliest other session that occupies the room of
+// session while session would. It returns ErrNotFound if there is none.
+func (s *SessionStore) GetConflicting(ctx context.Context, session *Session) (*Session, error) {
+	query := `
+		SELECT s.id, s.movie_id, s.room_id, s.start_time, s.end_time, s.occupied_until, s.format, s.price,
+		       s.status, s.cancelled_at, s.cancellation_reason,
+		       m.id, m.title, m.description, m.duration, m.release_date,
+		       r.id, r.name, r.capacity
+		FROM sessions s
+		JOIN movies m ON s.movie_id = m.id
+		JOIN rooms r ON s.room_id = r.id
+		WHERE s.room_id = $1 AND s.id <> $2 AND s.status = $5
+			AND tstzrange(s.start_time, s.occupied_until) && tstzrange($3, $4)
+		ORDER BY s.start_time
+		LIMIT 1
+	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	res, err := s.db.ExecContext(ctx, query, id)
+	conflict := &Session{}
+
+	err := s.db.QueryRowContext(ctx, query, session.RoomID, session.ID, session.StartTime, session.OccupiedUntil, SessionStatusScheduled).Scan(
+		&conflict.ID, &conflict.MovieID, &conflict.RoomID, &conflict.StartTime, &conflict.EndTime, &conflict.OccupiedUntil, &conflict.Format, &conflict.Price,
+		&conflict.Status, &conflict.CancelledAt, &conflict.CancellationReason,
+		&conflict.Movie.ID, &conflict.Movie.Title, &conflict.Movie.Description, &conflict.Movie.Duration, &conflict.Movie.ReleaseDate,
+		&conflict.Room.ID, &conflict.Room.Name, &conflict.Room.Capacity,
+	)
 	if err != nil {
-		return err
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
 	}
 
-	rows, err := res.RowsAffected()
+	return conflict, nil
+}
+
+// Create stores the session. It returns ErrSessionConflict if the room is
+// occupied by another session at that time.
+func (s *SessionStore) Create(ctx context.Context, session *Session) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return createSession(ctx, tx, session)
+	})
+}
+
+// CreateBatch stores all sessions or none of them. It returns
+// ErrSessionConflict if any of them overlaps another session of its room.
+func (s *SessionStore) CreateBatch(ctx context.Context, sessions []*Session) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		for _, session := range sessions {
+			if err := createSession(ctx, tx, session); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func createSession(ctx context.Context, tx *sql.Tx, session *Session) error {
+	query := `
+		INSERT INTO sessions (movie_id, room_id, start_time, end_time, occupied_until, format, price) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, status
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := tx.QueryRowContext(
+		ctx, query,
+		session.MovieID, session.RoomID, session.StartTime, session.EndTime, session.OccupiedUntil, session.Format, session.Price,
+	).Scan(&session.ID, &session.Status)
 	if err != nil {
-		return err
+		switch {
+		case err.Error() == `pq: conflicting key value violates exclusion constraint "sessions_room_time_excl"`:
+			return ErrSessionConflict
+		default:
+			return err
+		}
 	}
 
-	if rows == 0 {
-		return ErrNotFound
-	}
+	return setSessionPrices(ctx, tx, session.ID, session.Prices)
+}
 
-	return nil
+// Delete deletes a session nobody has a ticket for. It returns
+// ErrSessionHasTickets if a seat has been booked, sold or refunded, as such
+// sessions can only be cancelled.
+func (s *SessionStore) Delete(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var hasTickets bool
+		err := tx.QueryRowContext(
+			ctx,
+			`SELECT EXISTS (
+				SELECT 1 FROM tickets WHERE session_id = s.id AND status NOT IN ($2, $3)
+			)
+			FROM sessions s
+			WHERE s.id = $1
+			FOR UPDATE`,
+			id, TicketStatusFailed, TicketStatusExpired,
+		).Scan(&hasTickets)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if hasTickets {
+			return ErrSessionHasTickets
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1`, id)
+		return err
+	})
 }
 
+// Cancel cancels a scheduled session, freeing its room, and releases the seats
+// held for it. Pending orders with a seat at the session expire as a whole, so
+// a payment arriving for one of them later is refunded. Tickets already paid
+// for are left to be refunded one by one.
+func (s *SessionStore) Cancel(ctx context.Context, session *Session, reason string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			`UPDATE sessions SET status = $1, cancelled_at = NOW(), cancellation_reason = NULLIF($2, '')
+			WHERE id = $3 AND status = $4
+			RETURNING status, cancelled_at, cancellation_reason`,
+			SessionStatusCancelled, reason, session.ID, SessionStatusScheduled,
+		).Scan(&session.Status, &session.CancelledAt, &session.CancellationReason)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrInvalidTransition
+			default:
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`WITH expired_orders AS (
+				UPDATE orders SET status = $1
+				WHERE status = $2 AND id IN (
+					SELECT order_id FROM tickets WHERE session_id = $3 AND status = $4
+				)
+				RETURNING id
+			), released AS (
+				UPDATE tickets SET status = $5
+				WHERE status = $4 AND (session_id = $3 OR order_id IN (SELECT id FROM expired_orders))
+				RETURNING id
+			)
+			DELETE FROM seat_holds WHERE ticket_id IN (SELECT id FROM released)`,
+			OrderStatusExpired, OrderStatusPending, session.ID, TicketStatusPending, TicketStatusExpired,
+		)
+		return err
+	})
+}
+
+// Update stores the session. It returns ErrSessionConflict if the room is
+// occupied by another session at that time.
 func (s *SessionStore) Update(ctx context.Context, session *Session) error {
 	query := `
 		UPDATE sessions 
-		SET movie_id = $1, room_id = $2, start_time = $3, price = $4
-		WHERE id = $5
+		SET movie_id = $1, room_id = $2, start_time = $3, end_time = $4, occupied_until = $5, format = $6, price = $7
+		WHERE id = $8
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
@@ -158,11 +388,16 @@ func (s *SessionStore) Update(ctx context.Context, session *Session) error {
 
 	res, err := s.db.ExecContext(
 		ctx, query,
-		session.MovieID, session.RoomID, session.StartTime, session.Price,
+		session.MovieID, session.RoomID, session.StartTime, session.EndTime, session.OccupiedUntil, session.Format, session.Price,
 		session.ID,
 	)
 	if err != nil {
-		return err
+		switch {
+		case err.Error() == `pq: conflicting key value violates exclusion constraint "sessions_room_time_excl"`:
+			return ErrSessionConflict
+		default:
+			return err
+		}
 	}
 
 	rows, err := res.RowsAffected()
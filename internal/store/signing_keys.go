@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SigningKey is a private key used to sign access tokens, encoded in PKCS #8
+// form.
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey []byte
+	NotBefore  time.Time
+	CreatedAt  string
+}
+
+type SigningKeyStore struct {
+	db *sql.DB
+}
+
+// GetAll returns every stored key, from the oldest to the newest.
+func (s *SigningKeyStore) GetAll(ctx context.Context) ([]SigningKey, error) {
+	query := `
+		SELECT id, algorithm, private_key, not_before, created_at
+		FROM signing_keys
+		ORDER BY not_before, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []SigningKey
+	for rows.Next() {
+		var key SigningKey
+		if err := rows.Scan(&key.ID, &key.Algorithm, &key.PrivateKey, &key.NotBefore, &key.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (s *SigningKeyStore) Create(ctx context.Context, key *SigningKey) error {
+	query := `
+		INSERT INTO signing_keys (id, algorithm, private_key, not_before)
+		VALUES ($1, $2, $3, $4) RETURNING created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return s.db.QueryRowContext(
+		ctx, query,
+		key.ID, key.Algorithm, key.PrivateKey, key.NotBefore,
+	).Scan(&key.CreatedAt)
+}
+
+// DeleteRetired drops every key that was replaced by a newer one at or before
+// the given time.
+func (s *SigningKeyStore) DeleteRetired(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM signing_keys k
+		WHERE EXISTS (
+			SELECT 1 FROM signing_keys n
+			WHERE n.not_before > k.not_before AND n.not_before <= $1
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
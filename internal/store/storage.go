@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"time"
+
+	"github.com/k5sha/Tikceto/internal/lockout"
 )
 
 var (
@@ -18,9 +20,20 @@ type Storage struct {
 	Users interface {
 		GetByID(context.Context, int64) (*User, error)
 		GetByEmail(context.Context, string) (*User, error)
+		GetAccount(context.Context, int64) (*User, error)
+		GetList(context.Context, PaginatedUsersQuery) ([]User, int, error)
 		Create(context.Context, *sql.Tx, *User) error
-		CreateAndInvite(context.Context, *User, string, time.Duration) error
+		CreateAndInvite(context.Context, *User, string, time.Duration, *Mail) error
 		Activate(context.Context, string) error
+		RenewInvitation(context.Context, int64, string, time.Duration, *Mail) error
+		DeleteInactive(context.Context, time.Time) (int64, error)
+		CreatePasswordReset(context.Context, int64, string, time.Duration) error
+		ResetPassword(context.Context, string, *User) error
+		CreateAccountDeletion(context.Context, int64, string, time.Duration) error
+		DeleteAccount(context.Context, string) (*User, error)
+		SetRole(context.Context, int64, string) error
+		Ban(context.Context, int64, string) error
+		Unban(context.Context, int64) error
 		Delete(context.Context, int64) error
 	}
 	Rooms interface {
@@ -42,7 +55,14 @@ type Storage struct {
 	Sessions interface {
 		GetByID(context.Context, int64) (*Session, error)
 		GetByMovieID(context.Context, int64) ([]SessionWithoutMovie, error)
+		GetByRoom(context.Context, int64, time.Time, time.Time) ([]Session, error)
+		GetTimetable(context.Context, PaginatedSessionsQuery) ([]TimetableSession, int, error)
+		GetConflicting(context.Context, *Session) (*Session, error)
+		GetPrices(context.Context, int64) ([]SessionPrice, error)
+		SetPrices(context.Context, int64, []SessionPrice) error
 		Create(context.Context, *Session) error
+		CreateBatch(context.Context, []*Session) error
+		Cancel(context.Context, *Session, string) error
 		Delete(context.Context, int64) error
 		Update(context.Context, *Session) error
 	}
@@ -56,25 +76,103 @@ type Storage struct {
 	Tickets interface {
 		GetByID(context.Context, string) (*Ticket, error)
 		GetBySessionAndSeat(context.Context, int64, int64) (*Ticket, error)
+		GetConfirmedBySession(context.Context, int64) ([]Ticket, error)
 		GetByUserID(context.Context, int64) ([]Ticket, error)
 		Create(context.Context, *Ticket) error
 		Delete(context.Context, string) error
 		Update(context.Context, *Ticket) error
+		CheckIn(context.Context, *Ticket, int64) error
+	}
+	Orders interface {
+		GetByID(context.Context, string) (*Order, error)
+		Create(context.Context, *Order, time.Duration) error
+	}
+	Payments interface {
+		GetByID(context.Context, int64) (*Payment, error)
+		GetByProviderOrderID(context.Context, string, string) (*Payment, error)
+		GetByOrderID(context.Context, string) (*Payment, error)
+		GetByUserID(context.Context, int64) ([]Payment, error)
+		GetList(context.Context, PaginatedPaymentsQuery) ([]Payment, int, error)
+		GetPending(context.Context, string, time.Time, time.Time) ([]Payment, error)
+		GetEvents(context.Context, int64) ([]PaymentEvent, error)
+		Create(context.Context, *Payment) error
+		Update(context.Context, *Payment) error
+		RecordEvent(context.Context, *PaymentEvent) error
+		Settle(context.Context, *Payment, *PaymentEvent) error
+	}
+	Refunds interface {
+		GetByPaymentID(context.Context, int64) ([]Refund, error)
+		Create(context.Context, *Refund) error
+		Complete(context.Context, *Refund) error
+		Fail(context.Context, *Refund) error
+	}
+	Holds interface {
+		ReleaseExpired(context.Context) (int64, error)
+	}
+	Tokens interface {
+		CreateFamily(context.Context, int64, string, time.Duration, bool) (*RefreshToken, error)
+		Rotate(context.Context, string, string, time.Duration) (*RefreshToken, error)
+		IsRevoked(context.Context, string) (bool, error)
+		RevokeFamily(context.Context, string) error
+		RevokeUser(context.Context, int64) error
+	}
+	TwoFactor interface {
+		Get(context.Context, int64) (*TwoFactor, error)
+		Enroll(context.Context, int64, string) error
+		Enable(context.Context, int64, int64, []string) error
+		UseCode(context.Context, int64, int64) error
+		UseRecoveryCode(context.Context, int64, string) error
+		Disable(context.Context, int64) error
+	}
+	LoginAttempts interface {
+		Get(context.Context, string) (lockout.Attempt, error)
+		Update(context.Context, string, func(*lockout.Attempt)) (lockout.Attempt, error)
+		Delete(context.Context, string) error
+		DeleteStale(context.Context, time.Time) (int64, error)
+	}
+	SigningKeys interface {
+		GetAll(context.Context) ([]SigningKey, error)
+		Create(context.Context, *SigningKey) error
+		DeleteRetired(context.Context, time.Time) (int64, error)
+	}
+	Mail interface {
+		Enqueue(context.Context, *Mail) error
+		ClaimDue(context.Context, int, time.Duration) ([]Mail, error)
+		MarkSent(context.Context, int64) error
+		MarkFailed(context.Context, int64, string, *time.Time) error
 	}
 	Roles interface {
 		GetByName(context.Context, string) (*Role, error)
+		GetByID(context.Context, int64) (*Role, error)
+		GetAll(context.Context) ([]Role, error)
+		Create(context.Context, *Role) error
+		Update(context.Context, *Role) error
+		SetPermissions(context.Context, int64, []string) error
+		Delete(context.Context, int64) error
+		HasPermission(context.Context, int64, string) (bool, error)
+		Includes(context.Context, int64, int64) (bool, error)
+		GetPermissions(context.Context) ([]Permission, error)
 	}
 }
 
 func NewStorage(db *sql.DB) Storage {
 	return Storage{
-		Users:    &UsersStore{db},
-		Rooms:    &RoomsStore{db},
-		Movies:   &MoviesStore{db},
-		Sessions: &SessionStore{db},
-		Seats:    &SeatStore{db},
-		Tickets:  &TicketStore{db},
-		Roles:    &RolesStore{db},
+		Users:         &UsersStore{db},
+		Rooms:         &RoomsStore{db},
+		Movies:        &MoviesStore{db},
+		Sessions:      &SessionStore{db},
+		Seats:         &SeatStore{db},
+		Tickets:       &TicketStore{db},
+		Orders:        &OrderStore{db},
+		Payments:      &PaymentStore{db},
+		Refunds:       &RefundStore{db},
+		Holds:         &HoldStore{db},
+		Mail:          &MailStore{db},
+		Tokens:        &TokenStore{db},
+		TwoFactor:     &TwoFactorStore{db},
+		SigningKeys:   &SigningKeyStore{db},
+		LoginAttempts: &LoginAttemptStore{db},
+		Roles:         &RolesStore{db},
 	}
 }
 
@@ -8,27 +8,45 @@ import (
 
 var (
 	ErrDuplicateTicket = errors.New("a ticket with that session and seat already exists")
+	ErrTicketUsed      = errors.New("the ticket has already been used")
+)
+
+const (
+	TicketStatusPending   = "pending"
+	TicketStatusConfirmed = "confirmed"
+	TicketStatusFailed    = "failed"
+	TicketStatusExpired   = "expired"
+	TicketStatusRefunded  = "refunded"
+	TicketStatusUsed      = "used"
 )
 
 type Ticket struct {
 	ID        string  `json:"id"`
+	OrderID   *string `json:"order_id,omitempty"`
 	SessionID int64   `json:"session_id"`
 	SeatID    int64   `json:"seat_id"`
-	UserID    int64   `json:"user_id"`
+	UserID    *int64  `json:"user_id"`
 	Price     float64 `json:"price"`
 	CreatedAt string  `json:"created_at"`
 	Status    string  `json:"status"`
+	UsedAt    *string `json:"used_at,omitempty"`
 	Session   Session `json:"session"`
 	Seat      Seat    `json:"seat"`
 }
 
+// OwnedBy reports whether the ticket belongs to the user. Tickets of deleted
+// accounts belong to no one.
+func (t *Ticket) OwnedBy(userID int64) bool {
+	return t.UserID != nil && *t.UserID == userID
+}
+
 type TicketStore struct {
 	db *sql.DB
 }
 
 func (s *TicketStore) GetByID(ctx context.Context, id string) (*Ticket, error) {
 	query := `
-		SELECT id, session_id, seat_id, user_id, price, status, created_at
+		SELECT id, order_id, session_id, seat_id, user_id, price, status, used_at, created_at
 		FROM tickets
 		WHERE id = $1
 	`
@@ -38,7 +56,7 @@ func (s *TicketStore) GetByID(ctx context.Context, id string) (*Ticket, error) {
 
 	ticket := &Ticket{}
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&ticket.ID, &ticket.SessionID, &ticket.SeatID, &ticket.UserID, &ticket.Price, &ticket.Status, &ticket.CreatedAt,
+		&ticket.ID, &ticket.OrderID, &ticket.SessionID, &ticket.SeatID, &ticket.UserID, &ticket.Price, &ticket.Status, &ticket.UsedAt, &ticket.CreatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -57,7 +75,7 @@ func (s *TicketStore) GetByUserID(ctx context.Context, id int64) ([]Ticket, erro
 			s.id, s.movie_id, s.room_id, s.start_time, s.price,
 			m.id, m.title, m.description, m.duration, m.poster_url, m.release_date, m.created_at,
 			r.id, r.name, r.capacity,
-			se.id, se.room_id, se.row, se.seat_number
+			se.id, se.room_id, se.row, se.seat_number, se.type
 		FROM tickets t
 		JOIN sessions s ON t.session_id = s.id
 		JOIN movies m ON s.movie_id = m.id
@@ -88,7 +106,7 @@ func (s *TicketStore) GetByUserID(ctx context.Context, id int64) ([]Ticket, erro
 			&session.ID, &session.MovieID, &session.RoomID, &session.StartTime, &session.Price,
 			&movie.ID, &movie.Title, &movie.Description, &movie.Duration, &movie.PosterUrl, &movie.ReleaseDate, &movie.CreatedAt,
 			&room.ID, &room.Name, &room.Capacity,
-			&seat.ID, &seat.RoomID, &seat.Row, &seat.Number,
+			&seat.ID, &seat.RoomID, &seat.Row, &seat.Number, &seat.Type,
 		)
 		if err != nil {
 			return nil, err
@@ -113,6 +131,47 @@ func (s *TicketStore) GetByUserID(ctx context.Context, id int64) ([]Ticket, erro
 	return tickets, nil
 }
 
+// GetConfirmedBySession returns the paid tickets of a session that have not
+// been used yet, with their seats.
+func (s *TicketStore) GetConfirmedBySession(ctx context.Context, sessionID int64) ([]Ticket, error) {
+	query := `
+		SELECT t.id, t.order_id, t.session_id, t.seat_id, t.user_id, t.price, t.status, t.created_at,
+		       se.id, se.room_id, se.row, se.seat_number, se.type
+		FROM tickets t
+		JOIN seats se ON t.seat_id = se.id
+		WHERE t.session_id = $1 AND t.status = $2
+		ORDER BY t.order_id, se.row, se.seat_number
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, sessionID, TicketStatusConfirmed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []Ticket
+	for rows.Next() {
+		var ticket Ticket
+		err := rows.Scan(
+			&ticket.ID, &ticket.OrderID, &ticket.SessionID, &ticket.SeatID, &ticket.UserID, &ticket.Price, &ticket.Status, &ticket.CreatedAt,
+			&ticket.Seat.ID, &ticket.Seat.RoomID, &ticket.Seat.Row, &ticket.Seat.Number, &ticket.Seat.Type,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tickets, nil
+}
+
 func (s *TicketStore) GetBySessionAndSeat(ctx context.Context, sessionID, seatID int64) (*Ticket, error) {
 	query := `
 		SELECT 
@@ -120,7 +179,7 @@ func (s *TicketStore) GetBySessionAndSeat(ctx context.Context, sessionID, seatID
 			se.seat_number
 		FROM tickets t
 		JOIN seats se ON t.seat_id = se.id
-		WHERE t.session_id = $1 AND t.seat_id = $2
+		WHERE t.session_id = $1 AND t.seat_id = $2 AND t.status IN ($3, $4, $5)
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
@@ -128,7 +187,7 @@ func (s *TicketStore) GetBySessionAndSeat(ctx context.Context, sessionID, seatID
 
 	ticket := &Ticket{}
 
-	err := s.db.QueryRowContext(ctx, query, sessionID, seatID).Scan(
+	err := s.db.QueryRowContext(ctx, query, sessionID, seatID, TicketStatusPending, TicketStatusConfirmed, TicketStatusUsed).Scan(
 		&ticket.ID, &ticket.UserID, &ticket.Price, &ticket.CreatedAt, &ticket.Status,
 		&ticket.Seat.Number,
 	)
@@ -142,21 +201,37 @@ func (s *TicketStore) GetBySessionAndSeat(ctx context.Context, sessionID, seatID
 	return ticket, nil
 }
 
+// Create stores a ticket issued outside of an order. Such a ticket is never
+// paid for, so it is confirmed right away rather than held.
 func (s *TicketStore) Create(ctx context.Context, ticket *Ticket) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return createTicket(ctx, tx, ticket, TicketStatusConfirmed)
+	})
+}
+
+// createTicket stores a ticket with the given status. It returns ErrSessionCancelled if the
+// session has been cancelled; the session is locked until the transaction
+// ends so that it cannot be cancelled in the meantime.
+func createTicket(ctx context.Context, tx *sql.Tx, ticket *Ticket, status string) error {
 	query := `
-		INSERT INTO tickets (session_id, seat_id, price, user_id, status)
-		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at
+		INSERT INTO tickets (order_id, session_id, seat_id, price, user_id, status)
+		SELECT $1, s.id, $3, $4, $5, $6
+		FROM (SELECT id FROM sessions WHERE id = $2 AND status = $7 FOR SHARE) s
+		RETURNING id, status, created_at
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	err := s.db.QueryRowContext(
+	err := tx.QueryRowContext(
 		ctx, query,
-		ticket.SessionID, ticket.SeatID, ticket.Price, ticket.UserID, "pending",
-	).Scan(&ticket.ID, &ticket.CreatedAt)
+		ticket.OrderID, ticket.SessionID, ticket.SeatID, ticket.Price, ticket.UserID, status, SessionStatusScheduled,
+	).Scan(&ticket.ID, &ticket.Status, &ticket.CreatedAt)
 
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSessionCancelled
+		}
 		if err.Error() == `pq: duplicate key value violates unique constraint "tickets_session_id_seat_id_key"` {
 			return ErrDuplicateTicket
 		}
@@ -216,3 +291,44 @@ func (s *TicketStore) Update(ctx context.Context, ticket *Ticket) error {
 
 	return nil
 }
+
+// CheckIn marks a confirmed ticket as used by the given usher. A ticket can be
+// checked in only once; later attempts return ErrTicketUsed.
+func (s *TicketStore) CheckIn(ctx context.Context, ticket *Ticket, usherID int64) error {
+	query := `
+		UPDATE tickets SET status = $1, used_at = NOW(), checked_in_by = $2
+		WHERE id = $3 AND status = $4
+		RETURNING status, used_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx, query,
+		TicketStatusUsed, usherID, ticket.ID, TicketStatusConfirmed,
+	).Scan(&ticket.Status, &ticket.UsedAt)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	err = s.db.QueryRowContext(
+		ctx, `SELECT status, used_at FROM tickets WHERE id = $1`, ticket.ID,
+	).Scan(&ticket.Status, &ticket.UsedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	if ticket.Status == TicketStatusUsed {
+		return ErrTicketUsed
+	}
+
+	return ErrInvalidTransition
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+)
+
+// MovieSummary is the part of a movie shown next to its sessions.
+type MovieSummary struct {
+	ID          int64  `json:"id"`
+	Slug        string `json:"slug"`
+	Title       string `json:"title"`
+	Duration    int64  `json:"duration"`
+	PosterUrl   string `json:"poster_url"`
+	ReleaseDate string `json:"release_date"`
+}
+
+// SessionAvailability counts the seats of a session. Held seats belong to
+// orders that are not paid yet.
+type SessionAvailability struct {
+	Total int `json:"total"`
+	Free  int `json:"free"`
+	Held  int `json:"held"`
+	Sold  int `json:"sold"`
+}
+
+type TimetableSession struct {
+	ID           int64               `json:"id"`
+	MovieID      int64               `json:"movie_id"`
+	RoomID       int64               `json:"room_id"`
+	StartTime    string              `json:"start_time"`
+	EndTime      string              `json:"end_time"`
+	Format       string              `json:"format"`
+	Price        float64             `json:"price"`
+	Movie        MovieSummary        `json:"movie"`
+	Room         Room                `json:"room"`
+	Availability SessionAvailability `json:"availability"`
+}
+
+// GetTimetable returns the scheduled sessions matching the query ordered by
+// start time, with the seats still available for each, and the total number
+// of matches.
+func (s *SessionStore) GetTimetable(ctx context.Context, fq PaginatedSessionsQuery) ([]TimetableSession, int, error) {
+	query := `
+		SELECT s.id, s.movie_id, s.room_id, s.start_time, s.end_time, s.format, s.price,
+		       m.id, m.slug, m.title, m.duration, m.poster_url, m.release_date,
+		       r.id, r.name, r.capacity,
+		       seats.total, tickets.held, tickets.sold,
+		       COUNT(*) OVER() AS total_count
+		FROM sessions s
+		JOIN movies m ON m.id = s.movie_id
+		JOIN rooms r ON r.id = s.room_id
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS total FROM seats se WHERE se.room_id = s.room_id
+		) seats
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) FILTER (WHERE t.status = $1) AS held,
+			       COUNT(*) FILTER (WHERE t.status IN ($2, $3)) AS sold
+			FROM tickets t
+			WHERE t.session_id = s.id AND t.status IN ($1, $2, $3)
+		) tickets
+		WHERE s.status = $13 AND s.start_time >= $4 AND s.start_time < $5 AND
+			($6::bigint = 0 OR s.room_id = $6) AND
+			($7::bigint = 0 OR s.movie_id = $7) AND
+			($8 = '' OR s.format = $8) AND
+			($9::numeric IS NULL OR s.price >= $9) AND
+			($10::numeric IS NULL OR s.price <= $10)
+		ORDER BY s.start_time, s.id
+		LIMIT $11 OFFSET $12
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx, query,
+		TicketStatusPending, TicketStatusConfirmed, TicketStatusUsed,
+		fq.Since, fq.Until, fq.RoomID, fq.MovieID, fq.Format, fq.MinPrice, fq.MaxPrice,
+		fq.Limit, fq.Offset, SessionStatusScheduled,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var sessions []TimetableSession
+	totalCount := 0
+
+	for rows.Next() {
+		var session TimetableSession
+		availability := &session.Availability
+
+		err := rows.Scan(
+			&session.ID, &session.MovieID, &session.RoomID, &session.StartTime, &session.EndTime, &session.Format, &session.Price,
+			&session.Movie.ID, &session.Movie.Slug, &session.Movie.Title, &session.Movie.Duration, &session.Movie.PosterUrl, &session.Movie.ReleaseDate,
+			&session.Room.ID, &session.Room.Name, &session.Room.Capacity,
+			&availability.Total, &availability.Held, &availability.Sold,
+			&totalCount,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		availability.Free = max(availability.Total-availability.Held-availability.Sold, 0)
+
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return sessions, totalCount, nil
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrTokenExpired = errors.New("the token has expired")
+	ErrTokenRevoked = errors.New("the token has been revoked")
+	ErrTokenReused  = errors.New("the refresh token has already been used")
+)
+
+// RefreshToken is the session a refresh token belongs to. Every rotation of
+// a refresh token stays in the same family, so a whole login session can be
+// revoked at once.
+type RefreshToken struct {
+	FamilyID string `json:"family_id"`
+	UserID   int64  `json:"user_id"`
+	MFA      bool   `json:"mfa"`
+}
+
+type TokenStore struct {
+	db *sql.DB
+}
+
+// CreateFamily starts a new login session for the user with the given hashed
+// refresh token, valid for exp. mfa records whether the user passed a second
+// factor when signing in.
+func (s *TokenStore) CreateFamily(ctx context.Context, userID int64, token string, exp time.Duration, mfa bool) (*RefreshToken, error) {
+	refresh := &RefreshToken{UserID: userID, MFA: mfa}
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		err := tx.QueryRowContext(
+			ctx, `INSERT INTO token_families (user_id, mfa) VALUES ($1, $2) RETURNING id`, userID, mfa,
+		).Scan(&refresh.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		return createRefreshToken(ctx, tx, refresh.FamilyID, token, exp)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refresh, nil
+}
+
+// Rotate exchanges a hashed refresh token for a new one in the same family.
+// Presenting a token that was already exchanged means it has leaked, so the
+// whole family is revoked and ErrTokenReused is returned.
+func (s *TokenStore) Rotate(ctx context.Context, token, newToken string, exp time.Duration) (*RefreshToken, error) {
+	refresh := &RefreshToken{}
+	reused := false
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		query := `
+			SELECT f.id, f.user_id, f.mfa, f.revoked_at IS NOT NULL, rt.used_at IS NOT NULL, rt.expiry <= NOW()
+			FROM refresh_tokens rt
+			JOIN token_families f ON f.id = rt.family_id
+			WHERE rt.token = $1
+			FOR UPDATE
+		`
+
+		var revoked, used, expired bool
+		err := tx.QueryRowContext(ctx, query, token).Scan(&refresh.FamilyID, &refresh.UserID, &refresh.MFA, &revoked, &used, &expired)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		switch {
+		case revoked:
+			return ErrTokenRevoked
+		case used:
+			reused = true
+			return revokeFamily(ctx, tx, refresh.FamilyID)
+		case expired:
+			return ErrTokenExpired
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE token = $1`, token)
+		if err != nil {
+			return err
+		}
+
+		return createRefreshToken(ctx, tx, refresh.FamilyID, newToken, exp)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		return nil, ErrTokenReused
+	}
+
+	return refresh, nil
+}
+
+// IsRevoked reports whether the login session has been revoked.
+func (s *TokenStore) IsRevoked(ctx context.Context, familyID string) (bool, error) {
+	query := `SELECT revoked_at IS NOT NULL FROM token_families WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, query, familyID).Scan(&revoked)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, ErrNotFound
+		default:
+			return false, err
+		}
+	}
+
+	return revoked, nil
+}
+
+func (s *TokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return revokeFamily(ctx, tx, familyID)
+	})
+}
+
+// RevokeUser revokes every login session of the user.
+func (s *TokenStore) RevokeUser(ctx context.Context, userID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return revokeUserTokens(ctx, tx, userID)
+	})
+}
+
+func createRefreshToken(ctx context.Context, tx *sql.Tx, familyID, token string, exp time.Duration) error {
+	query := `INSERT INTO refresh_tokens (token, family_id, expiry) VALUES ($1, $2, $3)`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, token, familyID, time.Now().Add(exp))
+	return err
+}
+
+func revokeFamily(ctx context.Context, tx *sql.Tx, familyID string) error {
+	query := `UPDATE token_families SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, familyID)
+	return err
+}
+
+func revokeUserTokens(ctx context.Context, tx *sql.Tx, userID int64) error {
+	query := `UPDATE token_families SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, userID)
+	return err
+}
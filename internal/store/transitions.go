@@ -0,0 +1,38 @@
+package store
+
+import "errors"
+
+var (
+	ErrInvalidTransition = errors.New("invalid status transition")
+)
+
+var ticketTransitions = map[string][]string{
+	TicketStatusPending:   {TicketStatusConfirmed, TicketStatusFailed, TicketStatusExpired},
+	TicketStatusConfirmed: {TicketStatusRefunded, TicketStatusUsed},
+}
+
+var paymentTransitions = map[string][]string{
+	PaymentStatusPending:   {PaymentStatusConfirmed, PaymentStatusFailed},
+	PaymentStatusConfirmed: {PaymentStatusRefunded},
+}
+
+// CanTransitionTicket reports whether a ticket may move from one status to
+// another. Failed, expired, refunded and used tickets are final.
+func CanTransitionTicket(from, to string) bool {
+	return canTransition(ticketTransitions, from, to)
+}
+
+// CanTransitionPayment reports whether a payment may move from one status to
+// another. Failed and refunded payments are final.
+func CanTransitionPayment(from, to string) bool {
+	return canTransition(paymentTransitions, from, to)
+}
+
+func canTransition(transitions map[string][]string, from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+	ErrCodeUsed         = errors.New("the code has already been used")
+)
+
+// TwoFactor is the TOTP enrollment of a user. It only protects the account
+// once Enabled, i.e. after the user proved that their authenticator works.
+type TwoFactor struct {
+	UserID      int64
+	Secret      string
+	LastCounter int64
+	Enabled     bool
+}
+
+type TwoFactorStore struct {
+	db *sql.DB
+}
+
+func (s *TwoFactorStore) Get(ctx context.Context, userID int64) (*TwoFactor, error) {
+	query := `SELECT user_id, secret, last_counter, enabled_at IS NOT NULL FROM user_totp WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	tf := &TwoFactor{}
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&tf.UserID, &tf.Secret, &tf.LastCounter, &tf.Enabled)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return tf, nil
+}
+
+// Enroll stores a new TOTP secret for the user, replacing an unfinished
+// enrollment. It returns ErrTwoFactorEnabled if two-factor authentication is
+// already enabled.
+func (s *TwoFactorStore) Enroll(ctx context.Context, userID int64, secret string) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_counter = 0, created_at = NOW()
+		WHERE user_totp.enabled_at IS NULL
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// Enable turns on two-factor authentication after the first valid code,
+// which belongs to the given time step, and replaces the recovery codes with
+// the given hashed ones.
+func (s *TwoFactorStore) Enable(ctx context.Context, userID, counter int64, recoveryCodes []string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(
+			ctx,
+			`UPDATE user_totp SET enabled_at = NOW(), last_counter = $2 WHERE user_id = $1 AND enabled_at IS NULL`,
+			userID, counter,
+		)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+
+		for _, code := range recoveryCodes {
+			_, err := tx.ExecContext(ctx, `INSERT INTO recovery_codes (user_id, code) VALUES ($1, $2)`, userID, code)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// UseCode records that a TOTP code of the given time step was accepted. It
+// returns ErrCodeUsed if a code of that or a later step was accepted before,
+// so a code cannot be replayed.
+func (s *TwoFactorStore) UseCode(ctx context.Context, userID, counter int64) error {
+	query := `
+		UPDATE user_totp SET last_counter = $2
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_counter < $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, userID, counter)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrCodeUsed
+	}
+
+	return nil
+}
+
+// UseRecoveryCode spends a hashed recovery code of the user. It returns
+// ErrNotFound if the code does not exist or was spent before.
+func (s *TwoFactorStore) UseRecoveryCode(ctx context.Context, userID int64, code string) error {
+	query := `UPDATE recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code = $2 AND used_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, userID, code)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Disable removes the TOTP secret and the recovery codes of the user.
+func (s *TwoFactorStore) Disable(ctx context.Context, userID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -22,6 +22,8 @@ type User struct {
 	Password  password `json:"-"`
 	CreatedAt string   `json:"created_at,omitempty"`
 	IsActive  bool     `json:"is_active,omitempty"`
+	BannedAt  *string  `json:"banned_at,omitempty"`
+	BanReason *string  `json:"ban_reason,omitempty"`
 	RoleID    int64    `json:"role_id,omitempty"`
 	Role      Role     `json:"role"`
 }
@@ -88,7 +90,9 @@ func (s *UsersStore) Create(ctx context.Context, tx *sql.Tx, user *User) error {
 	return nil
 }
 
-func (s *UsersStore) CreateAndInvite(ctx context.Context, user *User, token string, invitationExp time.Duration) error {
+// CreateAndInvite stores the user together with an invitation and queues the
+// activation email in the same transaction.
+func (s *UsersStore) CreateAndInvite(ctx context.Context, user *User, token string, invitationExp time.Duration, mail *Mail) error {
 	return withTx(s.db, ctx, func(tx *sql.Tx) error {
 		if err := s.Create(ctx, tx, user); err != nil {
 			return err
@@ -99,7 +103,7 @@ func (s *UsersStore) CreateAndInvite(ctx context.Context, user *User, token stri
 			return err
 		}
 
-		return nil
+		return enqueueMail(ctx, tx, mail)
 	})
 }
 
@@ -124,11 +128,14 @@ func (s *UsersStore) Activate(ctx context.Context, token string) error {
 
 }
 
+// getUserFromInvitation returns the user invited with the plain token. It
+// returns ErrTokenExpired if the invitation has expired, so that the caller
+// can offer to send a new one.
 func (s *UsersStore) getUserFromInvitation(ctx context.Context, tx *sql.Tx, token string) (*User, error) {
-	query := `SELECT u.id, u.username, u.email, u.created_at, u.is_activate
+	query := `SELECT u.id, u.username, u.email, u.created_at, u.is_activate, ui.expiry
 		FROM users u
 		JOIN user_invitations ui ON ui.user_id = u.id
-		WHERE ui.token = $1 AND ui.expiry > $2
+		WHERE ui.token = $1
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
@@ -139,12 +146,14 @@ func (s *UsersStore) getUserFromInvitation(ctx context.Context, tx *sql.Tx, toke
 	hash := sha256.Sum256([]byte(token))
 	hashToken := hex.EncodeToString(hash[:])
 
-	err := tx.QueryRowContext(ctx, query, hashToken, time.Now()).Scan(
+	var expiry time.Time
+	err := tx.QueryRowContext(ctx, query, hashToken).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
 		&user.CreatedAt,
 		&user.IsActive,
+		&expiry,
 	)
 	if err != nil {
 		switch {
@@ -155,12 +164,16 @@ func (s *UsersStore) getUserFromInvitation(ctx context.Context, tx *sql.Tx, toke
 		}
 	}
 
+	if !expiry.After(time.Now()) {
+		return nil, ErrTokenExpired
+	}
+
 	return user, nil
 }
 
 func (s *UsersStore) GetByID(ctx context.Context, id int64) (*User, error) {
 	query := `
-		SELECT u.id, u.username, u.email, u.password, u.created_at, r.id, r.name, r.level, r.description
+		SELECT u.id, u.username, u.email, u.password, u.created_at, u.banned_at, r.id, r.name, r.description, r.requires_mfa
 		FROM users AS u
 		JOIN roles AS r ON (u.role_id = r.id)
         WHERE u.id = $1 AND is_activate = true`
@@ -175,10 +188,11 @@ func (s *UsersStore) GetByID(ctx context.Context, id int64) (*User, error) {
 		&user.Email,
 		&user.Password.hash,
 		&user.CreatedAt,
+		&user.BannedAt,
 		&user.Role.ID,
 		&user.Role.Name,
-		&user.Role.Level,
 		&user.Role.Description,
+		&user.Role.RequiresMFA,
 	)
 	if err != nil {
 		switch {
@@ -206,13 +220,32 @@ func (s *UsersStore) update(ctx context.Context, tx *sql.Tx, user *User) error {
 	return nil
 }
 
+// delete removes the user together with every email queued for them. Their
+// orders, tickets and payments are kept for accounting but no longer point to
+// anyone. The payloads the payment provider sent for their payments are
+// erased, as they carry the contact details of the buyer.
 func (s *UsersStore) delete(ctx context.Context, tx *sql.Tx, id int64) error {
-	query := `DELETE FROM users WHERE id = $1`
+	query := `
+		WITH user_payments AS (
+			SELECT p.id, p.provider, p.provider_order_id
+			FROM payments p
+			JOIN orders o ON o.id = p.order_id
+			WHERE o.user_id = $1
+		), erased AS (
+			UPDATE payment_events e SET raw_payload = $2
+			FROM user_payments p
+			WHERE e.payment_id = p.id
+				OR (e.provider = p.provider AND e.provider_order_id = p.provider_order_id)
+		), deleted AS (
+			DELETE FROM users WHERE id = $1 RETURNING email
+		)
+		DELETE FROM mail_outbox WHERE email IN (SELECT email FROM deleted)
+	`
 
 	ctx, cancel := context.WithTimeout(ctx, 3*QueryTimeoutDuration)
 	defer cancel()
 
-	_, err := tx.ExecContext(ctx, query, id)
+	_, err := tx.ExecContext(ctx, query, id, ErasedPayload)
 	if err != nil {
 		return err
 	}
@@ -232,8 +265,10 @@ func (s *UsersStore) Delete(ctx context.Context, id int64) error {
 	})
 }
 
+// GetByEmail returns the user with the given email whether or not the account
+// has been activated yet; callers check IsActive.
 func (s *UsersStore) GetByEmail(ctx context.Context, email string) (*User, error) {
-	query := `SELECT id, username, email, password, created_at FROM users WHERE email = $1 AND is_activate = true`
+	query := `SELECT id, username, email, password, created_at, is_activate, banned_at FROM users WHERE email = $1`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
@@ -245,6 +280,8 @@ func (s *UsersStore) GetByEmail(ctx context.Context, email string) (*User, error
 		&user.Email,
 		&user.Password.hash,
 		&user.CreatedAt,
+		&user.IsActive,
+		&user.BannedAt,
 	)
 	if err != nil {
 		switch {
@@ -257,3 +294,62 @@ func (s *UsersStore) GetByEmail(ctx context.Context, email string) (*User, error
 
 	return user, nil
 }
+
+// RenewInvitation replaces every outstanding invitation of a user who has not
+// activated the account yet with a new one and queues the activation email
+// for it. It returns ErrNotFound if there is no such user.
+func (s *UsersStore) RenewInvitation(ctx context.Context, userID int64, token string, invitationExp time.Duration, mail *Mail) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var isActive bool
+		err := tx.QueryRowContext(ctx, `SELECT is_activate FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&isActive)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if isActive {
+			return ErrNotFound
+		}
+
+		if err := s.deleteUserInvitations(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		if err := s.createUserInvitations(ctx, tx, token, invitationExp, userID); err != nil {
+			return err
+		}
+
+		return enqueueMail(ctx, tx, mail)
+	})
+}
+
+// DeleteInactive removes accounts that were never activated, were created
+// before the given time and have no invitation valid after it. It returns the
+// number of removed accounts.
+func (s *UsersStore) DeleteInactive(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		WITH purged AS (
+			DELETE FROM users u
+			WHERE is_activate = false AND created_at < $1
+				AND NOT EXISTS (SELECT 1 FROM user_invitations ui WHERE ui.user_id = u.id AND ui.expiry > $1)
+			RETURNING id
+		), invitations AS (
+			DELETE FROM user_invitations WHERE user_id IN (SELECT id FROM purged)
+		)
+		SELECT COUNT(*) FROM purged
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 3*QueryTimeoutDuration)
+	defer cancel()
+
+	var deleted int64
+	if err := s.db.QueryRowContext(ctx, query, before).Scan(&deleted); err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,171 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// GetList returns users matching the query, whatever their status, with the
+// total number of matches.
+func (s *UsersStore) GetList(ctx context.Context, fq PaginatedUsersQuery) ([]User, int, error) {
+	query := `
+		SELECT u.id, u.username, u.email, u.created_at, u.is_activate, u.banned_at, u.ban_reason,
+			r.id, r.name, r.description, r.requires_mfa,
+			COUNT(*) OVER() AS total_count
+		FROM users u
+		JOIN roles r ON r.id = u.role_id
+		WHERE
+			($1 = '' OR u.email ILIKE '%' || $1 || '%' OR u.username ILIKE '%' || $1 || '%') AND
+			($2 = '' OR r.name = $2) AND
+			($3 = '' OR
+				($3 = 'active' AND u.is_activate AND u.banned_at IS NULL) OR
+				($3 = 'inactive' AND NOT u.is_activate) OR
+				($3 = 'banned' AND u.banned_at IS NOT NULL))
+		ORDER BY u.created_at ` + fq.Sort + `, u.id ` + fq.Sort + `
+		LIMIT $4 OFFSET $5
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, fq.Search, fq.Role, fq.Status, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []User
+	totalCount := 0
+
+	for rows.Next() {
+		var user User
+		err := rows.Scan(
+			&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.IsActive, &user.BannedAt, &user.BanReason,
+			&user.Role.ID, &user.Role.Name, &user.Role.Description, &user.Role.RequiresMFA,
+			&totalCount,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return users, totalCount, nil
+}
+
+// GetAccount returns the user with the given ID whatever its status, unlike
+// GetByID which only finds users allowed to sign in.
+func (s *UsersStore) GetAccount(ctx context.Context, id int64) (*User, error) {
+	query := `
+		SELECT u.id, u.username, u.email, u.created_at, u.is_activate, u.banned_at, u.ban_reason,
+			r.id, r.name, r.description, r.requires_mfa
+		FROM users u
+		JOIN roles r ON r.id = u.role_id
+		WHERE u.id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	user := &User{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.IsActive, &user.BannedAt, &user.BanReason,
+		&user.Role.ID, &user.Role.Name, &user.Role.Description, &user.Role.RequiresMFA,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// SetRole assigns the named role to the user. It returns ErrNotFound if
+// either of them does not exist.
+func (s *UsersStore) SetRole(ctx context.Context, userID int64, role string) error {
+	query := `
+		UPDATE users SET role_id = r.id
+		FROM roles r
+		WHERE users.id = $1 AND r.name = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, userID, role)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Ban blocks the user from signing in and ends every session of the user.
+func (s *UsersStore) Ban(ctx context.Context, userID int64, reason string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(
+			ctx,
+			`UPDATE users SET banned_at = COALESCE(banned_at, NOW()), ban_reason = $2 WHERE id = $1`,
+			userID, reason,
+		)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return revokeUserTokens(ctx, tx, userID)
+	})
+}
+
+func (s *UsersStore) Unban(ctx context.Context, userID int64) error {
+	query := `UPDATE users SET banned_at = NULL, ban_reason = NULL WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}